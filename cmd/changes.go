@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"log"
+	"strings"
+
+	"github.com/netr/listy/iom"
+	"github.com/spf13/cobra"
+)
+
+// changesCmd represents the changes command
+var changesCmd = &cobra.Command{
+	Use:   "changes",
+	Short: "Report added, removed and unchanged lines between two versions of a list",
+	Long: `Compare an old and new version of a list and write the added, removed and unchanged lines to
+{new}-added, {new}-removed and {new}-unchanged, with a JSON summary. Output default ` + "`{new}-changes.json`" + `
+
+With --key, lines are matched by the fields at the given ids and lines whose key matches but
+whose other fields changed are written to {new}-modified.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		oldFile := validateFlag(cmd, "old")
+		newFile := validateFlag(cmd, "new")
+		out := getFlag(cmd, "out", strings.TrimSuffix(newFile, iom.GetFileExtension(newFile))+"-changes.json")
+
+		key, err := iom.ParseKeySelector(getFlag(cmd, "delim"), getFlag(cmd, "key"))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.Printf("Comparing %s to %s", oldFile, newFile)
+		summary, err := iom.ChangesFiles(oldFile, newFile, key)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err = iom.WriteJSON(out, summary); err != nil {
+			log.Fatal(err)
+		}
+
+		log.Printf("Added %d, removed %d, modified %d, unchanged %d", summary.Added, summary.Removed, summary.Modified, summary.Unchanged)
+		if summary.Skipped > 0 {
+			log.Printf("Skipped %d lines without a key", summary.Skipped)
+		}
+		log.Printf("Summary written to %s", out)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(changesCmd)
+	changesCmd.Flags().String("old", "", "Old version of the list")
+	changesCmd.Flags().String("new", "", "New version of the list")
+	changesCmd.Flags().StringP("out", "o", "", "Summary output file")
+	changesCmd.Flags().StringP("key", "k", "", "IDs of the fields to match lines by, e.g. 0 or 1,3")
+	changesCmd.Flags().StringP("delim", "s", ",", "Delimiter of the fields selected by --key")
+}
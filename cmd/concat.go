@@ -25,18 +25,22 @@ var concatCmd = &cobra.Command{
 		}
 
 		var result []string
+		var style *iom.LineStyle
 		for _, file := range files {
 			file = sanitizeFilename(dir + "/" + file)
 			log.Printf("Concatenating %s to %s ... ", file, out)
-			lines, err := iom.ReadFile(file)
+			lines, s, err := iom.ReadFileStyle(file)
 			if err != nil {
 				log.Fatal(err)
 			}
+			if style == nil {
+				style = s
+			}
 
 			result = append(result, lines...)
 		}
 
-		err = iom.WriteFile(out, result)
+		err = iom.WriteFileStyle(out, result, style)
 		if err != nil {
 			log.Fatal(err)
 		}
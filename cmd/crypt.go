@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/netr/listy/iom"
+	"github.com/spf13/cobra"
+)
+
+// encryptCmd represents the encrypt command
+var encryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt file(s) with a passphrase or keyfile",
+	Long: `Encrypt file(s) with AES-256-GCM, using a key derived from --keyfile or from the passphrase in
+--passphrase-file, the passphrase config key or the PASSPHRASE environment variable (argon2id).
+Output default ` + "`{file}-encrypted`" + `
+
+Every command reads encrypted files transparently, and writes encrypted files with --encrypt.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		e := iom.GetFileOptions().Encryption
+		if e == nil {
+			log.Fatal("encrypting requires --keyfile, --passphrase-file or the passphrase config key")
+		}
+
+		cryptFiles(cmd, "-encrypted", func(src, dst string) error {
+			log.Printf("Encrypting %s to %s", src, dst)
+			return iom.EncryptFile(src, dst, e)
+		})
+	},
+}
+
+// decryptCmd represents the decrypt command
+var decryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Decrypt encrypted file(s)",
+	Long: `Decrypt file(s) written with encrypt or --encrypt, using --keyfile or the passphrase in
+--passphrase-file, the passphrase config key or the PASSPHRASE environment variable.
+Output default ` + "`{file}-decrypted`",
+	Run: func(cmd *cobra.Command, args []string) {
+		cryptFiles(cmd, "-decrypted", func(src, dst string) error {
+			log.Printf("Decrypting %s to %s", src, dst)
+			return iom.DecryptFile(src, dst)
+		})
+	},
+}
+
+func cryptFiles(cmd *cobra.Command, suffix string, fn func(src, dst string) error) {
+	dir := getFlag(cmd, "dir")
+	if dir != "" {
+		files, err := iom.ReadDir(dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, file := range files {
+			file = sanitizeFilename(dir + "/" + file)
+			if err := fn(file, iom.AppendSuffixToFilename(file, suffix)); err != nil {
+				log.Fatal(err)
+			}
+		}
+		return
+	}
+
+	file := validateFlag(cmd, "file")
+	if err := fn(file, getFlag(cmd, "out", iom.AppendSuffixToFilename(file, suffix))); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(encryptCmd)
+	rootCmd.AddCommand(decryptCmd)
+	for _, c := range []*cobra.Command{encryptCmd, decryptCmd} {
+		c.Flags().StringP("file", "f", "", "File to process")
+		c.Flags().StringP("dir", "d", "", "Directory of files to process")
+		c.Flags().StringP("out", "o", "", "Output file")
+	}
+}
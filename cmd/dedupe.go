@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"log"
+	"sort"
 
 	"github.com/netr/listy/iom"
 	"github.com/spf13/cobra"
@@ -11,10 +12,17 @@ import (
 var dedupeCmd = &cobra.Command{
 	Use:   "dedupe",
 	Short: "Dedupe file(s)",
-	Long:  `Dedupe file(s)`,
+	Long: `Dedupe file(s)
+
+With --dir and --across, lines are deduped across every file in the directory instead of
+within each file. Files are processed in name order and the first file containing a line keeps it.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		dir := getFlag(cmd, "dir")
 		if dir != "" {
+			if getFlagBool(cmd, "across") {
+				dedupeDirAcross(cmd, dir)
+				return
+			}
 			dedupeDir(cmd, dir)
 			return
 		}
@@ -56,9 +64,50 @@ func dedupeDir(cmd *cobra.Command, dir string) {
 	}
 }
 
+func dedupeDirAcross(cmd *cobra.Command, dir string) {
+	log.Printf("Deduping directory %s across files\n\n", dir)
+
+	files, err := iom.ReadDir(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sort.Strings(files)
+
+	var srcs, dsts []string
+	for _, file := range files {
+		file = sanitizeFilename(dir + "/" + file)
+		srcs = append(srcs, file)
+		dsts = append(dsts, iom.AppendSuffixToFilename(file, "-deduped"))
+	}
+
+	lost, err := iom.RemoveDuplicatesAcrossFiles(srcs, dsts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var total int
+	for i, src := range srcs {
+		log.Printf("Deduped %s to %s", src, dsts[i])
+		for j, n := range lost[i] {
+			if n == 0 {
+				continue
+			}
+			if i == j {
+				log.Printf("  %d duplicates within %s", n, src)
+			} else {
+				log.Printf("  %d lines already in %s", n, srcs[j])
+			}
+			total += n
+		}
+	}
+
+	log.Printf("Removed %d duplicates across %d files", total, len(srcs))
+}
+
 func init() {
 	rootCmd.AddCommand(dedupeCmd)
 	dedupeCmd.Flags().StringP("file", "f", "", "File to dedupe")
 	dedupeCmd.Flags().StringP("dir", "d", "", "Directory to dedupe")
 	dedupeCmd.Flags().StringP("out", "o", "", "Output file")
+	dedupeCmd.Flags().BoolP("across", "a", false, "Dedupe across all files in --dir (first file by name wins)")
 }
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"log"
+	"strings"
 
 	"github.com/netr/listy/iom"
 	"github.com/spf13/cobra"
@@ -11,28 +12,61 @@ import (
 var diffCmd = &cobra.Command{
 	Use:   "diff",
 	Short: "Filter differences between file(s)",
-	Long:  `Filter lines of file(s) and output only those that are different from the other file(s)`,
+	Long: `Filter lines of file(s) and output only those that are different from the other file(s)
+
+With --key, lines are compared by the fields at the given ids instead of the whole line, while
+the full lines of the checked file(s) are written. The base file may use its own --base-key and
+--base-delim, which otherwise default to --key and --delim.
+
+With --ordered, the base and file are compared as ordered lists and a unified diff is written,
+which the patch command can apply to the base. Output default ` + "`{file}.patch`" + `.
+
+With --hashed-base instead of --base, the base is a file of hashes, e.g. written by the hash
+command, and each line (or --key) of the checked file(s) is hashed with --algo, --secret and
+--normalize before it is compared. Hashing options must match those used for the base.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if hashedBase := getFlag(cmd, "hashed-base"); hashedBase != "" {
+			diffHashed(cmd, hashedBase)
+			return
+		}
+
 		base := validateFlag(cmd, "base")
+		if getFlagBool(cmd, "ordered") {
+			diffOrdered(cmd, base)
+			return
+		}
+
+		baseKey, key := diffKeySelectors(cmd)
+
 		dir := getFlag(cmd, "dir")
 		if dir != "" {
-			diffDir(cmd, base, dir)
+			diffDir(cmd, base, dir, baseKey, key)
 			return
 		}
 
 		file := validateFlag(cmd, "file")
 		out := getFlag(cmd, "out", iom.AppendSuffixToFilename(file, "-diff"))
 
-		n, err := iom.DiffFiles(base, file, out)
+		if key.IsWholeLine() && baseKey.IsWholeLine() {
+			n, err := iom.DiffFiles(base, file, out)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			log.Printf("Found %d different lines", n)
+			return
+		}
+
+		n, skipped, err := iom.DiffFilesByKey(base, file, out, baseKey, key)
 		if err != nil {
 			log.Fatal(err)
 		}
 
-		log.Printf("Found %d different lines", n)
+		log.Printf("Found %d lines with different keys (%d lines without a key skipped)", n, skipped)
 	},
 }
 
-func diffDir(cmd *cobra.Command, base string, dir string) {
+func diffDir(cmd *cobra.Command, base string, dir string, baseKey, key iom.KeySelector) {
 	log.Printf("Getting differences from directory %s\n\n", dir)
 
 	if dir != "" {
@@ -42,41 +76,156 @@ func diffDir(cmd *cobra.Command, base string, dir string) {
 		}
 
 		var result []string
-		baseMap, err := iom.ReadFileToMap(base)
+		baseMap, err := iom.ReadFileToKeyMap(base, baseKey)
 		if err != nil {
 			log.Fatal(err)
 		}
 
 		var totalN int
+		var style *iom.LineStyle
 		for _, file := range files {
 			file = sanitizeFilename(dir + "/" + file)
 			if file == base {
 				continue
 			}
 
-			lines, err := iom.ReadFile(file)
+			lines, s, err := iom.ReadFileStyle(file)
 			if err != nil {
 				log.Fatal(err)
 			}
-
-			n, diff := iom.Diff(baseMap, lines)
-			if err != nil {
-				log.Fatal(err)
+			if style == nil {
+				style = s
 			}
+
+			n, skipped, diff := iom.DiffByKey(baseMap, lines, key)
 			result = append(result, diff...)
 			totalN += n
+			if skipped > 0 {
+				log.Printf("Skipped %d lines without a key in %s\n", skipped, file)
+			}
 			log.Printf("Found %d differences from %s\n", n, file)
 		}
 
-		iom.WriteFile(iom.AppendSuffixToFilename(base, "-diff"), result)
+		iom.WriteFileStyle(iom.AppendSuffixToFilename(base, "-diff"), result, style)
 		return
 	}
 }
 
+func diffOrdered(cmd *cobra.Command, base string) {
+	if getFlag(cmd, "dir") != "" || getFlag(cmd, "key") != "" {
+		log.Fatal("--ordered cannot be combined with --dir or --key")
+	}
+
+	file := validateFlag(cmd, "file")
+	out := getFlag(cmd, "out", strings.TrimSuffix(file, iom.GetFileExtension(file))+".patch")
+
+	log.Printf("Diffing %s to %s as ordered lists", base, file)
+	n, err := iom.UnifiedDiffFiles(base, file, out, getFlagInt(cmd, "context"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Found %d changed lines, unified diff written to %s", n, out)
+}
+
+func diffHashed(cmd *cobra.Command, hashedBase string) {
+	if getFlag(cmd, "base") != "" || getFlagBool(cmd, "ordered") {
+		log.Fatal("--hashed-base cannot be combined with --base or --ordered")
+	}
+
+	key, err := iom.ParseKeySelector(getFlag(cmd, "delim"), getFlag(cmd, "key"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	h := hasherFromFlags(cmd)
+
+	dir := getFlag(cmd, "dir")
+	if dir == "" {
+		file := validateFlag(cmd, "file")
+		out := getFlag(cmd, "out", iom.AppendSuffixToFilename(file, "-diff"))
+
+		log.Printf("Diffing %s against hashes in %s", file, hashedBase)
+		n, skipped, err := iom.DiffFilesHashed(hashedBase, file, out, key, h)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.Printf("Found %d lines not in the hashed base (%d lines without a key skipped)", n, skipped)
+		return
+	}
+
+	log.Printf("Getting differences from directory %s against hashes in %s\n\n", dir, hashedBase)
+
+	files, err := iom.ReadDir(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	hashes, err := iom.ReadHashesFile(hashedBase)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var result []string
+	var style *iom.LineStyle
+	for _, file := range files {
+		file = sanitizeFilename(dir + "/" + file)
+		if file == hashedBase {
+			continue
+		}
+
+		lines, s, err := iom.ReadFileStyle(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if style == nil {
+			style = s
+		}
+
+		n, skipped, diff := iom.DiffHashed(hashes, lines, key, h)
+		result = append(result, diff...)
+		if skipped > 0 {
+			log.Printf("Skipped %d lines without a key in %s\n", skipped, file)
+		}
+		log.Printf("Found %d differences from %s\n", n, file)
+	}
+
+	err = iom.WriteFileStyle(iom.AppendSuffixToFilename(hashedBase, "-diff"), result, style)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// diffKeySelectors returns the key selectors for the base file and the checked file(s)
+func diffKeySelectors(cmd *cobra.Command) (iom.KeySelector, iom.KeySelector) {
+	key, err := iom.ParseKeySelector(getFlag(cmd, "delim"), getFlag(cmd, "key"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	baseKey, err := iom.ParseKeySelector(
+		getFlag(cmd, "base-delim", getFlag(cmd, "delim")),
+		getFlag(cmd, "base-key", getFlag(cmd, "key")),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return baseKey, key
+}
+
 func init() {
 	rootCmd.AddCommand(diffCmd)
 	diffCmd.Flags().StringP("base", "b", "", "Base file to compare")
 	diffCmd.Flags().StringP("file", "f", "", "File to check against base")
 	diffCmd.Flags().StringP("dir", "d", "", "Directory of files to check against base")
 	diffCmd.Flags().StringP("out", "o", "", "Output file")
+	diffCmd.Flags().StringP("key", "k", "", "IDs of the fields to compare by, e.g. 0 or 1,3")
+	diffCmd.Flags().StringP("delim", "s", ",", "Delimiter of the fields selected by --key")
+	diffCmd.Flags().String("base-key", "", "IDs of the base file's fields to compare by (default --key)")
+	diffCmd.Flags().String("base-delim", "", "Delimiter of the base file's fields (default --delim)")
+	diffCmd.Flags().Bool("ordered", false, "Compare as ordered lists and write a unified diff")
+	diffCmd.Flags().IntP("context", "c", 3, "Lines of context in the unified diff")
+	diffCmd.Flags().String("hashed-base", "", "File of hashes to compare hashed lines against")
+	addHashFlags(diffCmd)
 }
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/netr/listy/iom"
+	"github.com/spf13/cobra"
+)
+
+// domainCmd represents the domain command
+var domainCmd = &cobra.Command{
+	Use:   "domain",
+	Short: "Normalize, validate, sort and group domain file(s)",
+	Long: `Process every line of file(s) as a domain: lines are trimmed, lower cased and stripped of a
+trailing dot, then the selected steps run in order. Output default ` + "`{file}-domains`" + `
+
+  --to-ascii       convert internationalized domains to punycode
+  --validate       reject invalid hostnames
+  --registrable    replace each host with its registrable domain (eTLD+1)
+  --to-unicode     convert punycode domains to unicode
+  --dedupe         remove duplicate domains
+  --sort           sort by reversed labels, so related hosts cluster together
+  --group          write one line per registrable domain: the apex, a tab and its hosts
+
+Registrable domains use the public suffix list embedded in the binary.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := iom.DomainOptions{
+			ToASCII:     getFlagBool(cmd, "to-ascii"),
+			ToUnicode:   getFlagBool(cmd, "to-unicode"),
+			Validate:    getFlagBool(cmd, "validate"),
+			Registrable: getFlagBool(cmd, "registrable"),
+			Dedupe:      getFlagBool(cmd, "dedupe"),
+			Sort:        getFlagBool(cmd, "sort"),
+			Group:       getFlagBool(cmd, "group"),
+		}
+		if opts.ToASCII && opts.ToUnicode {
+			log.Fatal("--to-ascii and --to-unicode cannot be combined")
+		}
+		rejects := getFlagBool(cmd, "rejects")
+
+		dir := getFlag(cmd, "dir")
+		if dir != "" {
+			log.Printf("Processing domains in directory %s\n\n", dir)
+
+			files, err := iom.ReadDir(dir)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			for _, file := range files {
+				file = sanitizeFilename(dir + "/" + file)
+				domainFile(file, iom.AppendSuffixToFilename(file, "-domains"), rejects, opts)
+				log.Println()
+			}
+			return
+		}
+
+		file := validateFlag(cmd, "file")
+		domainFile(file, getFlag(cmd, "out", iom.AppendSuffixToFilename(file, "-domains")), rejects, opts)
+	},
+}
+
+func domainFile(file, out string, rejects bool, opts iom.DomainOptions) {
+	rejectsOut := ""
+	if rejects {
+		rejectsOut = iom.AppendSuffixToFilename(file, "-rejected")
+	}
+
+	log.Printf("Processing domains in %s to %s", file, out)
+	n, rejected, err := iom.ProcessDomainsFile(file, out, rejectsOut, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Wrote %d lines, rejected %d domains", n, rejected)
+}
+
+func init() {
+	rootCmd.AddCommand(domainCmd)
+	domainCmd.Flags().StringP("file", "f", "", "File of domains")
+	domainCmd.Flags().StringP("dir", "d", "", "Directory of domain files")
+	domainCmd.Flags().StringP("out", "o", "", "Output file")
+	domainCmd.Flags().Bool("to-ascii", false, "Convert internationalized domains to punycode")
+	domainCmd.Flags().Bool("to-unicode", false, "Convert punycode domains to unicode")
+	domainCmd.Flags().BoolP("validate", "v", false, "Reject invalid hostnames")
+	domainCmd.Flags().BoolP("registrable", "e", false, "Replace hosts with their registrable domain (eTLD+1)")
+	domainCmd.Flags().BoolP("dedupe", "u", false, "Remove duplicate domains")
+	domainCmd.Flags().BoolP("sort", "s", false, "Sort by reversed labels")
+	domainCmd.Flags().BoolP("group", "g", false, "Group hosts under their registrable domain")
+	domainCmd.Flags().BoolP("rejects", "r", false, "Write rejected domains to {file}-rejected")
+}
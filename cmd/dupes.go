@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"log"
+	"sort"
+
+	"github.com/netr/listy/iom"
+	"github.com/spf13/cobra"
+)
+
+// dupesCmd represents the dupes command
+var dupesCmd = &cobra.Command{
+	Use:   "dupes",
+	Short: "Report duplicated lines with their positions in file(s)",
+	Long: `Report every duplicated line with its count and the file:line positions of each occurrence.
+Output default ` + "`{file}-dupes.txt` or `{dir}/dupes.txt`" + `, formatted as text or json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format := getFlag(cmd, "format")
+		if format != "text" && format != "json" {
+			log.Fatalf("Unknown format %q, expected text or json", format)
+		}
+
+		var files []string
+		var out string
+		dir := getFlag(cmd, "dir")
+		if dir != "" {
+			out = getFlag(cmd, "out", sanitizeFilename(dir+"/dupes."+dupesExt(format)))
+
+			names, err := iom.ReadDir(dir)
+			if err != nil {
+				log.Fatal(err)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				file := sanitizeFilename(dir + "/" + name)
+				if file == out {
+					continue
+				}
+				files = append(files, file)
+			}
+		} else {
+			file := validateFlag(cmd, "file")
+			out = getFlag(cmd, "out", iom.AppendSuffixToFilename(file, "-dupes"))
+			files = append(files, file)
+		}
+
+		log.Printf("Finding duplicates in %d file(s)", len(files))
+		dupes, err := iom.FindDuplicatesFiles(files)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if format == "json" {
+			err = iom.WriteJSON(out, dupes)
+		} else {
+			var lines []string
+			for _, d := range dupes {
+				lines = append(lines, d.String())
+			}
+			err = iom.WriteFile(out, lines)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		var removable int
+		for _, d := range dupes {
+			removable += d.Count - 1
+		}
+		log.Printf("Found %d duplicated lines (%d removable) written to %s", len(dupes), removable, out)
+	},
+}
+
+func dupesExt(format string) string {
+	if format == "json" {
+		return "json"
+	}
+	return "txt"
+}
+
+func init() {
+	rootCmd.AddCommand(dupesCmd)
+	dupesCmd.Flags().StringP("file", "f", "", "File to report duplicates of")
+	dupesCmd.Flags().StringP("dir", "d", "", "Directory to report duplicates across")
+	dupesCmd.Flags().StringP("out", "o", "", "Output file")
+	dupesCmd.Flags().StringP("format", "t", "text", "Output format: text or json")
+}
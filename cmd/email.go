@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/netr/listy/iom"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// emailCmd represents the email command
+var emailCmd = &cobra.Command{
+	Use:   "email",
+	Short: "Validate and normalize email lists",
+	Long: `Validate and normalize email lists.
+
+Addresses are validated against a practical subset of RFC 5322 and their domains are lower cased.
+Common domain typos (gmial.com) can be corrected, and with --canonicalize provider rules remove
+dots and +tags where the provider ignores them (gmail.com by default, more with --provider).
+Role accounts (admin@, info@) and disposable domains are flagged. The embedded disposable domain
+list is extended by the file given with --disposable or the disposable_domains config key.`,
+}
+
+// emailCleanCmd represents the email clean command
+var emailCleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Clean email file(s), writing rejected lines with reasons",
+	Long: `Clean email file(s): invalid addresses, and role or disposable addresses with --reject-role and
+--reject-disposable, are written to ` + "`{file}-rejected`" + ` followed by a tab and the reason.
+Output default ` + "`{file}-cleaned`",
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := emailOptions(cmd)
+		opts.RejectRole = getFlagBool(cmd, "reject-role")
+		opts.RejectDisposable = getFlagBool(cmd, "reject-disposable")
+
+		dir := getFlag(cmd, "dir")
+		if dir != "" {
+			files, err := iom.ReadDir(dir)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			for _, file := range files {
+				file = sanitizeFilename(dir + "/" + file)
+				emailCleanFile(file, iom.AppendSuffixToFilename(file, "-cleaned"), opts)
+				log.Println()
+			}
+			return
+		}
+
+		file := validateFlag(cmd, "file")
+		emailCleanFile(file, getFlag(cmd, "out", iom.AppendSuffixToFilename(file, "-cleaned")), opts)
+	},
+}
+
+func emailCleanFile(file, out string, opts iom.EmailOptions) {
+	rejects := iom.AppendSuffixToFilename(file, "-rejected")
+
+	log.Printf("Cleaning %s to %s", file, out)
+	kept, rejected, err := iom.CleanEmailsFile(file, out, rejects, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Kept %d addresses, %d rejected lines written to %s", kept, rejected, rejects)
+}
+
+// emailCheckCmd represents the email check command
+var emailCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Annotate every address of file(s) with its problems",
+	Long: `Write every line of file(s) followed by a tab and its flags: "ok", or a comma separated list of
+"invalid: reason", "typo:domain", "role" and "disposable". Output default ` + "`{file}-checked`",
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := emailOptions(cmd)
+
+		dir := getFlag(cmd, "dir")
+		if dir != "" {
+			files, err := iom.ReadDir(dir)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			for _, file := range files {
+				file = sanitizeFilename(dir + "/" + file)
+				emailCheckFile(file, iom.AppendSuffixToFilename(file, "-checked"), opts)
+				log.Println()
+			}
+			return
+		}
+
+		file := validateFlag(cmd, "file")
+		emailCheckFile(file, getFlag(cmd, "out", iom.AppendSuffixToFilename(file, "-checked")), opts)
+	},
+}
+
+func emailCheckFile(file, out string, opts iom.EmailOptions) {
+	log.Printf("Checking %s to %s", file, out)
+	n, err := iom.CheckEmailsFile(file, out, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("%d addresses have no problems", n)
+}
+
+func emailOptions(cmd *cobra.Command) iom.EmailOptions {
+	opts := iom.EmailOptions{
+		FixTypos:     getFlagBool(cmd, "fix-typos"),
+		Canonicalize: getFlagBool(cmd, "canonicalize"),
+		Providers:    iom.DefaultEmailProviders(),
+		Disposable:   iom.DisposableDomains(),
+	}
+
+	for _, v := range getFlagStrings(cmd, "provider") {
+		domain, p, err := iom.ParseEmailProvider(v)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.Providers[domain] = p
+	}
+
+	if file := getFlag(cmd, "disposable", viper.GetString("disposable_domains")); file != "" {
+		lines, err := iom.ReadConfigFile(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for d := range iom.ParseDisposableDomains(lines) {
+			opts.Disposable[d] = struct{}{}
+		}
+	}
+
+	return opts
+}
+
+func init() {
+	rootCmd.AddCommand(emailCmd)
+	emailCmd.AddCommand(emailCleanCmd)
+	emailCmd.AddCommand(emailCheckCmd)
+
+	emailCmd.PersistentFlags().StringP("file", "f", "", "File of email addresses")
+	emailCmd.PersistentFlags().StringP("dir", "d", "", "Directory of email address files")
+	emailCmd.PersistentFlags().StringP("out", "o", "", "Output file")
+	emailCmd.PersistentFlags().Bool("fix-typos", false, "Correct common domain typos, e.g. gmial.com")
+	emailCmd.PersistentFlags().Bool("canonicalize", false, "Apply provider rules, e.g. remove gmail dots and +tags")
+	emailCmd.PersistentFlags().StringArray("provider", nil, "Provider rule, e.g. example.com=dots,plus (repeatable)")
+	emailCmd.PersistentFlags().String("disposable", "", "File of additional disposable domains")
+	emailCleanCmd.Flags().Bool("reject-role", false, "Reject role accounts such as admin@ and info@")
+	emailCleanCmd.Flags().Bool("reject-disposable", false, "Reject addresses at disposable domains")
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/netr/listy/iom"
+	"github.com/spf13/cobra"
+)
+
+// encodingCmd represents the encoding command
+var encodingCmd = &cobra.Command{
+	Use:   "encoding",
+	Short: "Detect, check and convert character encodings of file(s)",
+	Long: `Every command converts input files to UTF-8: a byte order mark decides the encoding, otherwise
+text with many NUL bytes is UTF-16, valid UTF-8 is UTF-8 and anything else is windows-1252. Set
+the input encoding with --encoding and the output encoding with --out-encoding, e.g. utf-16le or
+windows-1252. UTF-16 output starts with a byte order mark.`,
+}
+
+// encodingDetectCmd represents the encoding detect command
+var encodingDetectCmd = &cobra.Command{
+	Use:   "detect",
+	Short: "Report the detected encoding of file(s)",
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, file := range encodingFiles(cmd) {
+			enc, err := iom.DetectFileEncoding(file)
+			if err != nil {
+				log.Fatal(err)
+			}
+			log.Printf("%s: %s", file, enc)
+		}
+	},
+}
+
+// encodingCheckCmd represents the encoding check command
+var encodingCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Report lines of file(s) that are not valid UTF-8",
+	Long: `Report every line of file(s) that is not valid UTF-8 as stored, as file:line. Lines are not
+converted from --encoding first, so a UTF-16 or windows-1252 file reports its non-ASCII lines.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var total int
+		for _, file := range encodingFiles(cmd) {
+			positions, err := iom.InvalidUTF8Lines(file)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			for _, p := range positions {
+				log.Printf("%s: invalid UTF-8", p)
+			}
+			total += len(positions)
+		}
+
+		log.Printf("Found %d invalid UTF-8 lines", total)
+	},
+}
+
+// encodingConvertCmd represents the encoding convert command
+var encodingConvertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "Convert file(s) from --encoding to --out-encoding",
+	Long: `Rewrite file(s) from --encoding (default detected) to --out-encoding (default UTF-8).
+Output default ` + "`{file}-converted`",
+	Run: func(cmd *cobra.Command, args []string) {
+		out := getFlag(cmd, "out")
+		for _, file := range encodingFiles(cmd) {
+			dst := iom.AppendSuffixToFilename(file, "-converted")
+			if out != "" && getFlag(cmd, "dir") == "" {
+				dst = out
+			}
+
+			log.Printf("Converting %s to %s", file, dst)
+			lines, style, err := iom.ReadFileStyle(file)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if err := iom.WriteFileStyle(dst, lines, style); err != nil {
+				log.Fatal(err)
+			}
+		}
+	},
+}
+
+// encodingFiles returns the --file or the files of --dir
+func encodingFiles(cmd *cobra.Command) []string {
+	dir := getFlag(cmd, "dir")
+	if dir == "" {
+		return []string{validateFlag(cmd, "file")}
+	}
+
+	names, err := iom.ReadDir(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	files := make([]string, len(names))
+	for i, name := range names {
+		files[i] = sanitizeFilename(dir + "/" + name)
+	}
+	return files
+}
+
+func init() {
+	rootCmd.AddCommand(encodingCmd)
+	encodingCmd.AddCommand(encodingDetectCmd)
+	encodingCmd.AddCommand(encodingCheckCmd)
+	encodingCmd.AddCommand(encodingConvertCmd)
+
+	encodingCmd.PersistentFlags().StringP("file", "f", "", "File to process")
+	encodingCmd.PersistentFlags().StringP("dir", "d", "", "Directory of files to process")
+	encodingConvertCmd.Flags().StringP("out", "o", "", "Output file")
+}
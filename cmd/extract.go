@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/netr/listy/iom"
+	"github.com/spf13/cobra"
+)
+
+// extractCmd represents the extract command
+var extractCmd = &cobra.Command{
+	Use:   "extract",
+	Short: "Extract emails, URLs, domains, IPs and phone numbers from file(s)",
+	Long: `Extract entities from free text in file(s) and write one per line.
+Output default ` + "`{file}-extracted` or `{dir}/extracted.txt`" + `
+
+Built in --type values: ` + strings.Join(iom.BuiltinExtractors(), ", ") + `. Custom patterns can be given
+with --regex; if a pattern has a capturing group, the first group is extracted.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var exs []iom.Extractor
+		if types := getFlag(cmd, "type"); types != "" {
+			for _, name := range strings.Split(types, ",") {
+				e, err := iom.BuiltinExtractor(strings.TrimSpace(name))
+				if err != nil {
+					log.Fatal(err)
+				}
+				exs = append(exs, e)
+			}
+		}
+		for _, expr := range getFlagStrings(cmd, "regex") {
+			e, err := iom.RegexExtractor(expr)
+			if err != nil {
+				log.Fatal(err)
+			}
+			exs = append(exs, e)
+		}
+		if len(exs) == 0 {
+			log.Fatal("Please provide a value for --type or --regex")
+		}
+
+		var files []string
+		var out string
+		dir := getFlag(cmd, "dir")
+		if dir != "" {
+			out = getFlag(cmd, "out", sanitizeFilename(dir+"/extracted.txt"))
+
+			names, err := iom.ReadDir(dir)
+			if err != nil {
+				log.Fatal(err)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				file := sanitizeFilename(dir + "/" + name)
+				if file == out {
+					continue
+				}
+				files = append(files, file)
+			}
+		} else {
+			file := validateFlag(cmd, "file")
+			out = getFlag(cmd, "out", iom.AppendSuffixToFilename(file, "-extracted"))
+			files = append(files, file)
+		}
+
+		log.Printf("Extracting from %d file(s) to %s", len(files), out)
+		n, err := iom.ExtractFiles(files, out, exs, getFlagBool(cmd, "dedupe"), getFlagBool(cmd, "annotate"))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.Printf("Extracted %d matches", n)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(extractCmd)
+	extractCmd.Flags().StringP("file", "f", "", "File to extract from")
+	extractCmd.Flags().StringP("dir", "d", "", "Directory to extract from")
+	extractCmd.Flags().StringP("out", "o", "", "Output file")
+	extractCmd.Flags().StringP("type", "t", "", "Comma separated built in extractors, e.g. email,url")
+	extractCmd.Flags().StringArrayP("regex", "r", nil, "Custom regex to extract (repeatable)")
+	extractCmd.Flags().BoolP("dedupe", "u", false, "Only write the first occurrence of each match")
+	extractCmd.Flags().BoolP("annotate", "a", false, "Prefix each match with its file:line and a tab")
+}
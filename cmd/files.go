@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/netr/listy/iom"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// setFileOptions applies the global file flags to every file listy reads and writes
+func setFileOptions(cmd *cobra.Command) {
+	opts := iom.FileOptions{
+		Encrypt:         getFlagBool(cmd, "encrypt"),
+		InputEncoding:   getFlag(cmd, "encoding"),
+		OutputEncoding:  getFlag(cmd, "out-encoding"),
+		EOL:             getFlag(cmd, "eol"),
+		FinalNewline:    getFlag(cmd, "final-newline"),
+		MaxLineLength:   getFlagInt(cmd, "max-line-length"),
+		RecordSep:       getFlag(cmd, "record-sep"),
+		OutputRecordSep: getFlag(cmd, "out-record-sep"),
+	}
+
+	e, err := encryptionFromFlags(cmd)
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts.Encryption = e
+
+	if err := iom.SetFileOptions(opts); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// encryptionFromFlags returns the encryption of --keyfile, --passphrase-file or the passphrase
+// config key, or nil if none is set
+func encryptionFromFlags(cmd *cobra.Command) (*iom.Encryption, error) {
+	keyfile := getFlag(cmd, "keyfile")
+	passphraseFile := getFlag(cmd, "passphrase-file")
+	if keyfile != "" && passphraseFile != "" {
+		log.Fatal("--keyfile and --passphrase-file cannot be combined")
+	}
+
+	if keyfile != "" {
+		return iom.ReadKeyfile(keyfile)
+	}
+
+	passphrase := viper.GetString("passphrase")
+	if passphraseFile != "" {
+		b, err := os.ReadFile(passphraseFile)
+		if err != nil {
+			return nil, err
+		}
+		passphrase = strings.TrimRight(string(b), "\r\n")
+	}
+	if passphrase == "" {
+		return nil, nil
+	}
+	return iom.NewPassphraseEncryption([]byte(passphrase))
+}
+
+func init() {
+	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		setFileOptions(cmd)
+	}
+
+	rootCmd.PersistentFlags().Bool("encrypt", false, "Encrypt output files with --keyfile or the passphrase")
+	rootCmd.PersistentFlags().String("keyfile", "", "Keyfile to encrypt and decrypt files with")
+	rootCmd.PersistentFlags().String("encoding", iom.EncodingAuto, "Encoding of input files, e.g. utf-16le or windows-1252, or auto to detect it")
+	rootCmd.PersistentFlags().String("out-encoding", iom.EncodingUTF8, "Encoding of output files")
+	rootCmd.PersistentFlags().String("eol", iom.EOLPreserve, "Line ending of output files: lf, crlf or preserve the input file's")
+	rootCmd.PersistentFlags().String("final-newline", iom.FinalNewlinePreserve, "End the last line of output files with a newline: always, never or preserve")
+	rootCmd.PersistentFlags().Int("max-line-length", 0, "Longest line in bytes to read, 0 for unlimited")
+	rootCmd.PersistentFlags().String("record-sep", iom.RecordSepNewline, `Separator of records read as lines: newline, nul, paragraph (blank lines), re:<regex> or a literal such as "\x1e"`)
+	rootCmd.PersistentFlags().String("out-record-sep", "", "Separator of records in output files (default --record-sep, newline for a regex)")
+	rootCmd.PersistentFlags().String("passphrase-file", "", "File holding the passphrase to encrypt and decrypt files with (default passphrase config key)")
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/netr/listy/iom"
+	"github.com/spf13/cobra"
+)
+
+// filterCmd represents the filter command
+var filterCmd = &cobra.Command{
+	Use:   "filter",
+	Short: "Filter lines of file(s) by pattern, length, charset and fields",
+	Long: `Keep the lines of file(s) that match any --include regex, no --exclude regex, are within
+--min-len and --max-len, contain only characters of the --charset classes and satisfy every
+--where field predicate. --invert keeps the other lines instead.
+
+Character classes: ascii, digits, alpha, alnum, printable and space.
+Field predicates: {id}={value}, {id}!={value}, {id}~{regex}, {id}!~{regex} and numeric
+{id}>{n}, {id}>={n}, {id}<{n}, {id}<={n}, e.g. --where '2~^foo' --where '3>100'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		f := filterFromFlags(cmd)
+		rejects := getFlagBool(cmd, "rejects")
+
+		dir := getFlag(cmd, "dir")
+		if dir != "" {
+			if getFlag(cmd, "rejects-out") != "" {
+				log.Fatal("--rejects-out cannot be combined with --dir, use --rejects to write `{file}-rejected` for each file")
+			}
+			filterDir(cmd, dir, f, rejects)
+			return
+		}
+
+		file := validateFlag(cmd, "file")
+		out := getFlag(cmd, "out", iom.AppendSuffixToFilename(file, "-filtered"))
+		rejectsOut := getFlag(cmd, "rejects-out")
+		if rejects && rejectsOut == "" {
+			rejectsOut = iom.AppendSuffixToFilename(file, "-rejected")
+		}
+		filterFile(file, out, rejectsOut, f)
+	},
+}
+
+func filterDir(cmd *cobra.Command, dir string, f *iom.Filter, rejects bool) {
+	log.Printf("Filtering directory %s\n\n", dir)
+
+	files, err := iom.ReadDir(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, file := range files {
+		file = sanitizeFilename(dir + "/" + file)
+		rejectsOut := ""
+		if rejects {
+			rejectsOut = iom.AppendSuffixToFilename(file, "-rejected")
+		}
+		filterFile(file, iom.AppendSuffixToFilename(file, "-filtered"), rejectsOut, f)
+		log.Println()
+	}
+}
+
+func filterFile(file, out, rejectsOut string, f *iom.Filter) {
+	log.Printf("Filtering %s to %s", file, out)
+	kept, rejected, err := iom.FilterFile(file, out, rejectsOut, f)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if rejectsOut != "" {
+		log.Printf("Kept %d lines, %d rejected lines written to %s", kept, rejected, rejectsOut)
+		return
+	}
+	log.Printf("Kept %d lines, rejected %d lines", kept, rejected)
+}
+
+func filterFromFlags(cmd *cobra.Command) *iom.Filter {
+	f := &iom.Filter{
+		MinLen: getFlagInt(cmd, "min-len"),
+		MaxLen: getFlagInt(cmd, "max-len"),
+		Delim:  getFlag(cmd, "delim"),
+		Invert: getFlagBool(cmd, "invert"),
+	}
+
+	for _, v := range getFlagStrings(cmd, "include") {
+		f.Include = append(f.Include, mustCompile(v))
+	}
+	for _, v := range getFlagStrings(cmd, "exclude") {
+		f.Exclude = append(f.Exclude, mustCompile(v))
+	}
+	if charset := getFlag(cmd, "charset"); charset != "" {
+		f.Charsets = strings.Split(charset, ",")
+	}
+	for _, v := range getFlagStrings(cmd, "where") {
+		p, err := iom.ParseFieldPredicate(v)
+		if err != nil {
+			log.Fatal(err)
+		}
+		f.Fields = append(f.Fields, p)
+	}
+
+	if err := f.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	return f
+}
+
+func mustCompile(expr string) *regexp.Regexp {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		log.Fatalf("Invalid regex %q: %v", expr, err)
+	}
+	return re
+}
+
+func init() {
+	rootCmd.AddCommand(filterCmd)
+	filterCmd.Flags().StringP("file", "f", "", "File to filter")
+	filterCmd.Flags().StringP("dir", "d", "", "Directory to filter")
+	filterCmd.Flags().StringP("out", "o", "", "Output file")
+	filterCmd.Flags().StringArrayP("include", "i", nil, "Keep lines matching this regex (repeatable)")
+	filterCmd.Flags().StringArrayP("exclude", "x", nil, "Drop lines matching this regex (repeatable)")
+	filterCmd.Flags().Int("min-len", 0, "Minimum line length in characters")
+	filterCmd.Flags().Int("max-len", 0, "Maximum line length in characters, 0 for no limit")
+	filterCmd.Flags().StringP("charset", "c", "", "Allowed character classes, e.g. ascii or digits,space")
+	filterCmd.Flags().StringArrayP("where", "w", nil, "Field predicate, e.g. '2~^foo' or '3>100' (repeatable)")
+	filterCmd.Flags().StringP("delim", "s", ",", "Delimiter of the fields tested by --where")
+	filterCmd.Flags().BoolP("invert", "v", false, "Keep the lines that would be rejected instead")
+	filterCmd.Flags().BoolP("rejects", "r", false, "Write rejected lines to {file}-rejected")
+	filterCmd.Flags().String("rejects-out", "", "Rejected lines output file, implies --rejects (file mode only)")
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/netr/listy/iom"
+	"github.com/spf13/cobra"
+)
+
+// fuzzyDedupeCmd represents the fuzzy-dedupe command
+var fuzzyDedupeCmd = &cobra.Command{
+	Use:   "fuzzy-dedupe",
+	Short: "Dedupe near-identical lines of file(s)",
+	Long: `Group near-identical lines of file(s) and keep one representative per group, or with --report
+write every group as tab separated group number and line.
+
+Lines are compared case and punctuation insensitively, either by the Jaccard similarity of their
+character shingles (minhash) or by edit distance between sorted neighbours (levenshtein).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := fuzzyOptions(cmd)
+		report := getFlagBool(cmd, "report")
+
+		dir := getFlag(cmd, "dir")
+		if dir != "" {
+			fuzzyDedupeDir(cmd, dir, opts, report)
+			return
+		}
+
+		file := validateFlag(cmd, "file")
+		out := getFlag(cmd, "out", iom.AppendSuffixToFilename(file, fuzzySuffix(report)))
+		fuzzyDedupeFile(file, out, opts, report)
+	},
+}
+
+func fuzzyDedupeDir(cmd *cobra.Command, dir string, opts iom.FuzzyOptions, report bool) {
+	log.Printf("Fuzzy deduping directory %s\n\n", dir)
+
+	files, err := iom.ReadDir(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, file := range files {
+		file = sanitizeFilename(dir + "/" + file)
+		fuzzyDedupeFile(file, iom.AppendSuffixToFilename(file, fuzzySuffix(report)), opts, report)
+		log.Println()
+	}
+}
+
+func fuzzyDedupeFile(file, out string, opts iom.FuzzyOptions, report bool) {
+	if report {
+		log.Printf("Clustering %s to %s using %s", file, out, opts.Method)
+		n, err := iom.FuzzyClusterReportFile(file, out, opts)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.Printf("Found %d clusters of near-duplicates", n)
+		return
+	}
+
+	log.Printf("Fuzzy deduping %s to %s using %s", file, out, opts.Method)
+	n, err := iom.RemoveFuzzyDuplicatesFile(file, out, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Removed %d near-duplicates", n)
+}
+
+func fuzzyOptions(cmd *cobra.Command) iom.FuzzyOptions {
+	opts := iom.DefaultFuzzyOptions()
+	opts.Method = getFlag(cmd, "method", opts.Method)
+	opts.Threshold = getFlagFloat(cmd, "threshold")
+	opts.Shingle = getFlagInt(cmd, "shingle")
+	opts.Hashes = getFlagInt(cmd, "hashes")
+	opts.Window = getFlagInt(cmd, "window")
+
+	if opts.Threshold <= 0 || opts.Threshold > 1 {
+		log.Fatalf("Please provide a --threshold between 0 and 1")
+	}
+
+	return opts
+}
+
+func fuzzySuffix(report bool) string {
+	if report {
+		return "-clusters"
+	}
+	return "-fuzzy"
+}
+
+func init() {
+	defaults := iom.DefaultFuzzyOptions()
+
+	rootCmd.AddCommand(fuzzyDedupeCmd)
+	fuzzyDedupeCmd.Flags().StringP("file", "f", "", "File to dedupe")
+	fuzzyDedupeCmd.Flags().StringP("dir", "d", "", "Directory to dedupe")
+	fuzzyDedupeCmd.Flags().StringP("out", "o", "", "Output file")
+	fuzzyDedupeCmd.Flags().StringP("method", "m", defaults.Method, "Similarity method: minhash or levenshtein")
+	fuzzyDedupeCmd.Flags().Float64P("threshold", "t", defaults.Threshold, "Minimum similarity (0-1) to treat lines as duplicates")
+	fuzzyDedupeCmd.Flags().Int("shingle", defaults.Shingle, "Characters per shingle (minhash)")
+	fuzzyDedupeCmd.Flags().Int("hashes", defaults.Hashes, "MinHash signature length (minhash)")
+	fuzzyDedupeCmd.Flags().Int("window", defaults.Window, "Sorted neighbours to compare each line to (levenshtein)")
+	fuzzyDedupeCmd.Flags().BoolP("report", "r", false, "Write a cluster report instead of deduped lines")
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/netr/listy/iom"
+	"github.com/spf13/cobra"
+)
+
+// groupCmd represents the group command
+var groupCmd = &cobra.Command{
+	Use:   "group",
+	Short: "Group file(s) by key fields and aggregate each group",
+	Long: `Group delimited lines by one or more key fields and write one line per group: the key followed
+by each aggregate, separated by --delim.
+
+Aggregates: count, distinct:{id}, min:{id}, max:{id}, sum:{id} and concat:{id}, e.g.
+--agg count,sum:2,concat:1. Min and max compare numerically when every value is a number.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := groupOptions(cmd)
+
+		dir := getFlag(cmd, "dir")
+		if dir != "" {
+			groupDir(cmd, dir, opts)
+			return
+		}
+
+		file := validateFlag(cmd, "file")
+		out := getFlag(cmd, "out", iom.AppendSuffixToFilename(file, "-grouped"))
+		groupFile(file, out, opts)
+	},
+}
+
+func groupDir(cmd *cobra.Command, dir string, opts iom.GroupOptions) {
+	log.Printf("Grouping directory %s\n\n", dir)
+
+	files, err := iom.ReadDir(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, file := range files {
+		file = sanitizeFilename(dir + "/" + file)
+		groupFile(file, iom.AppendSuffixToFilename(file, "-grouped"), opts)
+		log.Println()
+	}
+}
+
+func groupFile(file, out string, opts iom.GroupOptions) {
+	log.Printf("Grouping %s to %s", file, out)
+	n, skipped, err := iom.GroupByFile(file, out, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if skipped > 0 {
+		log.Printf("Skipped %d lines with missing or non-numeric fields", skipped)
+	}
+	log.Printf("Wrote %d groups to %s", n, out)
+}
+
+func groupOptions(cmd *cobra.Command) iom.GroupOptions {
+	key, err := iom.ParseKeySelector(getFlag(cmd, "delim"), validateFlag(cmd, "key"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	aggs, err := iom.ParseAggregates(getFlag(cmd, "agg"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return iom.GroupOptions{Key: key, Aggregates: aggs, Sep: getFlag(cmd, "sep")}
+}
+
+func init() {
+	rootCmd.AddCommand(groupCmd)
+	groupCmd.Flags().StringP("file", "f", "", "File to group")
+	groupCmd.Flags().StringP("dir", "d", "", "Directory to group")
+	groupCmd.Flags().StringP("out", "o", "", "Output file")
+	groupCmd.Flags().StringP("key", "k", "", "IDs of the fields to group by, e.g. 0 or 1,3")
+	groupCmd.Flags().StringP("delim", "s", ",", "Delimiter to split by")
+	groupCmd.Flags().StringP("agg", "a", "count", "Aggregates to compute per group")
+	groupCmd.Flags().String("sep", ";", "Separator of values joined by concat")
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"log"
+	"strings"
+
+	"github.com/netr/listy/iom"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// hashCmd represents the hash command
+var hashCmd = &cobra.Command{
+	Use:   "hash",
+	Short: "Hash lines or key fields of file(s)",
+	Long: `Hash every line of file(s), or the fields selected by --key, and write the hashes as lower case
+hex. Algorithms: ` + strings.Join(iom.HashAlgorithms(), ", ") + `. hmac-sha256 takes its secret from
+--secret or the hash_secret config key (or HASH_SECRET environment variable).
+
+Values are normalized before hashing with repeatable --normalize operations, which take the same
+operations as the transform command, e.g. --normalize trim --normalize lower. Partners must hash
+with the same algorithm and normalization for hashes to match, see diff --hashed-base.
+Output default ` + "`{file}-hashed`",
+	Run: func(cmd *cobra.Command, args []string) {
+		key, err := iom.ParseKeySelector(getFlag(cmd, "delim"), getFlag(cmd, "key"))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		opts := iom.HashOptions{
+			Hasher: hasherFromFlags(cmd),
+			Key:    key,
+			Append: getFlagBool(cmd, "append"),
+			Delim:  getFlag(cmd, "delim"),
+		}
+
+		dir := getFlag(cmd, "dir")
+		if dir != "" {
+			log.Printf("Hashing directory %s\n\n", dir)
+
+			files, err := iom.ReadDir(dir)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			for _, file := range files {
+				file = sanitizeFilename(dir + "/" + file)
+				hashFile(file, iom.AppendSuffixToFilename(file, "-hashed"), opts)
+				log.Println()
+			}
+			return
+		}
+
+		file := validateFlag(cmd, "file")
+		hashFile(file, getFlag(cmd, "out", iom.AppendSuffixToFilename(file, "-hashed")), opts)
+	},
+}
+
+func hashFile(file, out string, opts iom.HashOptions) {
+	log.Printf("Hashing %s to %s", file, out)
+	n, skipped, err := iom.HashFile(file, out, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Hashed %d lines (%d lines without a key skipped)", n, skipped)
+}
+
+// hasherFromFlags builds a hasher from the algo, secret and normalize flags
+func hasherFromFlags(cmd *cobra.Command) *iom.Hasher {
+	normalize, err := iom.ParseTransforms(getFlagStrings(cmd, "normalize"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	algo := getFlag(cmd, "algo")
+	secret := getFlag(cmd, "secret")
+	if secret == "" && algo == iom.HashHMACSHA256 {
+		secret = viper.GetString("hash_secret")
+	}
+
+	h, err := iom.NewHasher(algo, []byte(secret), normalize)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return h
+}
+
+// addHashFlags adds the flags read by hasherFromFlags
+func addHashFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("algo", "a", iom.HashSHA256, "Hash algorithm: "+strings.Join(iom.HashAlgorithms(), ", "))
+	cmd.Flags().String("secret", "", "Secret of hmac-sha256 (default hash_secret config key)")
+	cmd.Flags().StringArray("normalize", nil, "Transform operation applied before hashing (repeatable)")
+}
+
+func init() {
+	rootCmd.AddCommand(hashCmd)
+	hashCmd.Flags().StringP("file", "f", "", "File to hash")
+	hashCmd.Flags().StringP("dir", "d", "", "Directory of files to hash")
+	hashCmd.Flags().StringP("out", "o", "", "Output file")
+	hashCmd.Flags().StringP("key", "k", "", "IDs of the fields to hash, e.g. 0 or 1,3")
+	hashCmd.Flags().StringP("delim", "s", ",", "Delimiter of the fields selected by --key")
+	hashCmd.Flags().Bool("append", false, "Write each line followed by the delimiter and its hash")
+	addHashFlags(hashCmd)
+}
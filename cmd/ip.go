@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"log"
+	"strings"
+
+	"github.com/netr/listy/iom"
+	"github.com/spf13/cobra"
+)
+
+// ipCmd represents the ip command
+var ipCmd = &cobra.Command{
+	Use:   "ip",
+	Short: "Canonicalize, sort, aggregate and filter IP address file(s)",
+	Long: `Process every line of file(s) as an IPv4/IPv6 address, a CIDR or a start-end range. Entries are
+canonicalized (leading zeros stripped, IPv6 compressed, CIDRs masked to their network address),
+then the selected steps run in order. Output default ` + "`{file}-ip`" + `
+
+  --drop           drop entries overlapping address classes: ` + strings.Join(iom.IPClasses(), ", ") + `
+  --subtract       remove the addresses of a file of addresses, CIDRs and ranges (implies --aggregate)
+  --aggregate      merge entries into the fewest CIDRs
+  --expand         list every address of each entry, up to --max-expand addresses
+  --sort           sort numerically, IPv4 before IPv6
+  --dedupe         remove duplicate entries
+
+Ranges are written as the fewest CIDRs covering them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := iom.IPOptions{
+			Aggregate: getFlagBool(cmd, "aggregate"),
+			Expand:    getFlagBool(cmd, "expand"),
+			MaxExpand: getFlagInt(cmd, "max-expand"),
+			Dedupe:    getFlagBool(cmd, "dedupe"),
+			Sort:      getFlagBool(cmd, "sort"),
+		}
+		for _, v := range getFlagStrings(cmd, "drop") {
+			opts.Drop = append(opts.Drop, strings.Split(v, ",")...)
+		}
+		if subtract := getFlag(cmd, "subtract"); subtract != "" {
+			ps, err := iom.ReadPrefixesFile(subtract)
+			if err != nil {
+				log.Fatal(err)
+			}
+			opts.Subtract = ps
+		}
+		rejects := getFlagBool(cmd, "rejects")
+
+		dir := getFlag(cmd, "dir")
+		if dir != "" {
+			log.Printf("Processing IP addresses in directory %s\n\n", dir)
+
+			files, err := iom.ReadDir(dir)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			for _, file := range files {
+				file = sanitizeFilename(dir + "/" + file)
+				ipFile(file, iom.AppendSuffixToFilename(file, "-ip"), rejects, opts)
+				log.Println()
+			}
+			return
+		}
+
+		file := validateFlag(cmd, "file")
+		ipFile(file, getFlag(cmd, "out", iom.AppendSuffixToFilename(file, "-ip")), rejects, opts)
+	},
+}
+
+func ipFile(file, out string, rejects bool, opts iom.IPOptions) {
+	rejectsOut := ""
+	if rejects {
+		rejectsOut = iom.AppendSuffixToFilename(file, "-rejected")
+	}
+
+	log.Printf("Processing IP addresses in %s to %s", file, out)
+	n, rejected, err := iom.ProcessIPsFile(file, out, rejectsOut, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Wrote %d lines, rejected %d entries", n, rejected)
+}
+
+func init() {
+	rootCmd.AddCommand(ipCmd)
+	ipCmd.Flags().StringP("file", "f", "", "File of IP addresses")
+	ipCmd.Flags().StringP("dir", "d", "", "Directory of IP address files")
+	ipCmd.Flags().StringP("out", "o", "", "Output file")
+	ipCmd.Flags().StringArray("drop", nil, "Drop address classes, comma separated (can be repeated)")
+	ipCmd.Flags().StringP("subtract", "x", "", "File of addresses, CIDRs and ranges to remove")
+	ipCmd.Flags().BoolP("aggregate", "a", false, "Merge entries into the fewest CIDRs")
+	ipCmd.Flags().BoolP("expand", "e", false, "List every address of each entry")
+	ipCmd.Flags().Int("max-expand", 65536, "Maximum number of addresses to expand to (0 for unlimited)")
+	ipCmd.Flags().BoolP("dedupe", "u", false, "Remove duplicate entries")
+	ipCmd.Flags().BoolP("sort", "s", false, "Sort numerically")
+	ipCmd.Flags().BoolP("rejects", "r", false, "Write rejected entries to {file}-rejected")
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/netr/listy/iom"
+	"github.com/spf13/cobra"
+)
+
+// joinCmd represents the join command
+var joinCmd = &cobra.Command{
+	Use:   "join",
+	Short: "Join two files on a key field",
+	Long: `Join two files on a key field. Matching lines are written as the left line followed by the
+right line's non-key fields, unmatched lines are written unchanged. Output default ` + "`{left}-joined`" + `
+
+Modes: inner, left, right, full and anti (left lines without a match).
+
+By default the right file is held in memory (hash join). With --sorted, both files must be sorted
+by key in byte order and are streamed (merge join), so neither has to fit in memory.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		left := validateFlag(cmd, "left")
+		right := validateFlag(cmd, "right")
+		out := getFlag(cmd, "out", iom.AppendSuffixToFilename(left, "-joined"))
+
+		delim := getFlag(cmd, "delim")
+		leftKey, err := iom.ParseKeySelector(getFlag(cmd, "left-delim", delim), getFlag(cmd, "left-key", getFlag(cmd, "key")))
+		if err != nil {
+			log.Fatal(err)
+		}
+		rightKey, err := iom.ParseKeySelector(getFlag(cmd, "right-delim", delim), getFlag(cmd, "right-key", getFlag(cmd, "key")))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		opts := iom.JoinOptions{
+			Mode:     getFlag(cmd, "mode"),
+			LeftKey:  leftKey,
+			RightKey: rightKey,
+			Delim:    delim,
+		}
+
+		var stats iom.JoinStats
+		if getFlagBool(cmd, "sorted") {
+			log.Printf("Merge joining %s and %s (%s) to %s", left, right, opts.Mode, out)
+			stats, err = iom.MergeJoinFiles(left, right, out, opts)
+		} else {
+			log.Printf("Hash joining %s and %s (%s) to %s", left, right, opts.Mode, out)
+			stats, err = iom.HashJoinFiles(left, right, out, opts)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.Printf("Wrote %d matched, %d left only and %d right only lines", stats.Matched, stats.LeftOnly, stats.RightOnly)
+		if stats.Skipped > 0 {
+			log.Printf("Skipped %d lines without a key", stats.Skipped)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(joinCmd)
+	joinCmd.Flags().StringP("left", "l", "", "Left file")
+	joinCmd.Flags().StringP("right", "r", "", "Right file")
+	joinCmd.Flags().StringP("out", "o", "", "Output file")
+	joinCmd.Flags().StringP("mode", "m", iom.JoinInner, "Join mode: inner, left, right, full or anti")
+	joinCmd.Flags().StringP("key", "k", "0", "IDs of the key fields on both sides, e.g. 0 or 1,3")
+	joinCmd.Flags().StringP("delim", "s", ",", "Delimiter of both sides and of joined lines")
+	joinCmd.Flags().String("left-key", "", "IDs of the left key fields (default --key)")
+	joinCmd.Flags().String("left-delim", "", "Delimiter of the left file (default --delim)")
+	joinCmd.Flags().String("right-key", "", "IDs of the right key fields (default --key)")
+	joinCmd.Flags().String("right-delim", "", "Delimiter of the right file (default --delim)")
+	joinCmd.Flags().Bool("sorted", false, "Both files are sorted by key, stream them with a merge join")
+}
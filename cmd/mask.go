@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/netr/listy/iom"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// maskCmd represents the mask command
+var maskCmd = &cobra.Command{
+	Use:   "mask",
+	Short: "Mask, redact, tokenize or drop personal data in file(s)",
+	Long: `Mask every line of file(s) with repeatable --rule flags of the form <action>[:<target>]. The target
+is comma separated field ids split by --delim, e.g. email:2 or drop:3,4, or a regex after a ~ for
+unstructured lines, e.g. 'redact:~\d{3}-\d{2}-\d{4}'. Output default ` + "`{file}-masked`" + `
+
+  email      keep the first character and the domain, e.g. j***@example.com
+  phone      mask every digit but the last four, e.g. +* ***-***-2671
+  redact     replace the value with ***
+  tokenize   replace the value with a keyed pseudonym, the same for equal values
+  drop       remove the fields
+
+Without a target, email and phone mask every address or number found in the line, and redact
+and tokenize apply to the whole line. Field rules run first, using the original field positions,
+then dropped fields are removed and regex rules run. tokenize takes its secret from --secret or
+the mask_secret config key (or MASK_SECRET environment variable).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var rules []iom.MaskRule
+		for _, v := range getFlagStrings(cmd, "rule") {
+			r, err := iom.ParseMaskRule(v)
+			if err != nil {
+				log.Fatal(err)
+			}
+			rules = append(rules, r)
+		}
+		if len(rules) == 0 {
+			log.Fatal("at least one --rule is required")
+		}
+
+		secret := getFlag(cmd, "secret", viper.GetString("mask_secret"))
+		m, err := iom.NewMasker(rules, getFlag(cmd, "delim"), []byte(secret))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		dir := getFlag(cmd, "dir")
+		if dir != "" {
+			log.Printf("Masking directory %s\n\n", dir)
+
+			files, err := iom.ReadDir(dir)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			for _, file := range files {
+				file = sanitizeFilename(dir + "/" + file)
+				maskFile(file, iom.AppendSuffixToFilename(file, "-masked"), m)
+				log.Println()
+			}
+			return
+		}
+
+		file := validateFlag(cmd, "file")
+		maskFile(file, getFlag(cmd, "out", iom.AppendSuffixToFilename(file, "-masked")), m)
+	},
+}
+
+func maskFile(file, out string, m *iom.Masker) {
+	log.Printf("Masking %s to %s", file, out)
+	n, err := iom.MaskFile(file, out, m)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Masked %d lines", n)
+}
+
+func init() {
+	rootCmd.AddCommand(maskCmd)
+	maskCmd.Flags().StringP("file", "f", "", "File to mask")
+	maskCmd.Flags().StringP("dir", "d", "", "Directory of files to mask")
+	maskCmd.Flags().StringP("out", "o", "", "Output file")
+	maskCmd.Flags().StringArrayP("rule", "m", nil, "Mask rule, e.g. email:2 or 'redact:~[0-9]{4}' (repeatable)")
+	maskCmd.Flags().StringP("delim", "s", ",", "Delimiter of the fields selected by rules")
+	maskCmd.Flags().String("secret", "", "Secret of tokenize (default mask_secret config key)")
+}
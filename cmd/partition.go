@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"log"
+	"strings"
+
+	"github.com/netr/listy/iom"
+	"github.com/spf13/cobra"
+)
+
+// partitionCmd represents the partition command
+var partitionCmd = &cobra.Command{
+	Use:   "partition",
+	Short: "Partition file(s) into one file per key value",
+	Long: `Write each line of file(s) to ` + "`{out-dir}/{key}.txt`" + `, where the key is the value of the fields at
+--key, with a manifest of the written files and their line counts in ` + "`{out-dir}/manifest.json`" + `.
+Output directory default ` + "`{file}-partitions` or `{dir}/partitions`" + `
+
+Keys are sanitized into file names, and at most --max-open files are kept open at once. With
+--encrypt, a partition file reopened after being closed gets another encrypted stream, so raise
+--max-open above the number of keys to write each partition as a single stream.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var srcs []string
+		var outDir string
+
+		dir := getFlag(cmd, "dir")
+		if dir != "" {
+			outDir = getFlag(cmd, "out-dir", sanitizeFilename(dir+"/partitions"))
+
+			files, err := iom.ReadDir(dir)
+			if err != nil {
+				log.Fatal(err)
+			}
+			for _, file := range files {
+				file = sanitizeFilename(dir + "/" + file)
+				if file == outDir {
+					continue
+				}
+				srcs = append(srcs, file)
+			}
+		} else {
+			file := validateFlag(cmd, "file")
+			outDir = getFlag(cmd, "out-dir", strings.TrimSuffix(file, iom.GetFileExtension(file))+"-partitions")
+			srcs = append(srcs, file)
+		}
+
+		key, err := iom.ParseKeySelector(getFlag(cmd, "delim"), validateFlag(cmd, "key"))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		opts := iom.PartitionOptions{
+			Key:     key,
+			Dir:     outDir,
+			Ext:     getFlag(cmd, "ext"),
+			MaxOpen: getFlagInt(cmd, "max-open"),
+		}
+
+		log.Printf("Partitioning %d file(s) into %s", len(srcs), outDir)
+		manifest, skipped, err := iom.PartitionFiles(srcs, opts)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		out := sanitizeFilename(outDir + "/manifest.json")
+		if err = iom.WriteJSON(out, manifest); err != nil {
+			log.Fatal(err)
+		}
+
+		if skipped > 0 {
+			log.Printf("Skipped %d lines without a key", skipped)
+		}
+		log.Printf("Wrote %d partitions, manifest written to %s", len(manifest), out)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(partitionCmd)
+	partitionCmd.Flags().StringP("file", "f", "", "File to partition")
+	partitionCmd.Flags().StringP("dir", "d", "", "Directory of files to partition together")
+	partitionCmd.Flags().String("out-dir", "", "Directory to write partitions to")
+	partitionCmd.Flags().StringP("key", "k", "", "IDs of the fields to partition by, e.g. 0 or 1,3")
+	partitionCmd.Flags().StringP("delim", "s", ",", "Delimiter to split by")
+	partitionCmd.Flags().String("ext", ".txt", "Extension of partition files")
+	partitionCmd.Flags().Int("max-open", 64, "Maximum number of partition files open at once")
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/netr/listy/iom"
+	"github.com/spf13/cobra"
+)
+
+// patchCmd represents the patch command
+var patchCmd = &cobra.Command{
+	Use:   "patch",
+	Short: "Apply a unified diff from `diff --ordered` to a file",
+	Long:  `Apply a unified diff, as written by ` + "`diff --ordered`" + `, to a file to produce the new list`,
+	Run: func(cmd *cobra.Command, args []string) {
+		file := validateFlag(cmd, "file")
+		patch := validateFlag(cmd, "patch")
+		out := getFlag(cmd, "out", iom.AppendSuffixToFilename(file, "-patched"))
+
+		log.Printf("Patching %s with %s to %s", file, patch, out)
+		if err := iom.PatchFile(file, patch, out); err != nil {
+			log.Fatal(err)
+		}
+
+		count, err := iom.CountFileLines(out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Wrote %d lines to %s", count, out)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(patchCmd)
+	patchCmd.Flags().StringP("file", "f", "", "File to patch")
+	patchCmd.Flags().StringP("patch", "p", "", "Unified diff to apply")
+	patchCmd.Flags().StringP("out", "o", "", "Output file")
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/netr/listy/iom"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// phoneCmd represents the phone command
+var phoneCmd = &cobra.Command{
+	Use:   "phone",
+	Short: "Normalize phone number file(s) to E.164",
+	Long: `Normalize every line of file(s) as a phone number in E.164 format, e.g. +442079460000. Spaces,
+dashes, dots, slashes and parentheses are ignored. Numbers starting with + or 00 are international,
+others are local numbers of --country (a region such as GB or a calling code such as 44, default
+from the phone_country config key) and lose their trunk prefix, e.g. 020 7946 0000 in GB.
+
+Numbers are rejected if their length is invalid for their calling code, using the numbering
+metadata embedded in the binary. Output default ` + "`{file}-phones`",
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := iom.PhoneOptions{
+			Country:      getFlag(cmd, "country", viper.GetString("phone_country")),
+			Meta:         iom.DefaultPhoneMetadata(),
+			Dedupe:       getFlagBool(cmd, "dedupe"),
+			KeepOriginal: getFlagBool(cmd, "keep-original"),
+			Delim:        getFlag(cmd, "delim"),
+		}
+		if opts.Country != "" {
+			if _, ok := opts.Meta.Country(opts.Country); !ok {
+				log.Fatalf("unknown country %q", opts.Country)
+			}
+		}
+		rejects := getFlagBool(cmd, "rejects")
+
+		dir := getFlag(cmd, "dir")
+		if dir != "" {
+			log.Printf("Normalizing phone numbers in directory %s\n\n", dir)
+
+			files, err := iom.ReadDir(dir)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			for _, file := range files {
+				file = sanitizeFilename(dir + "/" + file)
+				phoneFile(file, iom.AppendSuffixToFilename(file, "-phones"), rejects, opts)
+				log.Println()
+			}
+			return
+		}
+
+		file := validateFlag(cmd, "file")
+		phoneFile(file, getFlag(cmd, "out", iom.AppendSuffixToFilename(file, "-phones")), rejects, opts)
+	},
+}
+
+func phoneFile(file, out string, rejects bool, opts iom.PhoneOptions) {
+	rejectsOut := ""
+	if rejects {
+		rejectsOut = iom.AppendSuffixToFilename(file, "-rejected")
+	}
+
+	log.Printf("Normalizing phone numbers in %s to %s", file, out)
+	n, rejected, err := iom.NormalizePhonesFile(file, out, rejectsOut, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Wrote %d numbers, rejected %d lines", n, rejected)
+}
+
+func init() {
+	rootCmd.AddCommand(phoneCmd)
+	phoneCmd.Flags().StringP("file", "f", "", "File of phone numbers")
+	phoneCmd.Flags().StringP("dir", "d", "", "Directory of phone number files")
+	phoneCmd.Flags().StringP("out", "o", "", "Output file")
+	phoneCmd.Flags().StringP("country", "c", "", "Region or calling code of local numbers, e.g. GB or 44")
+	phoneCmd.Flags().BoolP("dedupe", "u", false, "Remove duplicate numbers after normalization")
+	phoneCmd.Flags().BoolP("keep-original", "k", false, "Write the original line in a second column")
+	phoneCmd.Flags().StringP("delim", "s", "\t", "Delimiter of the original line column")
+	phoneCmd.Flags().BoolP("rejects", "r", false, "Write rejected lines to {file}-rejected")
+}
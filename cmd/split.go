@@ -28,13 +28,13 @@ var splitCmd = &cobra.Command{
 		out := getFlag(cmd, "out", iom.AppendSuffixToFilename(file, "-split"))
 
 		log.Printf("Spltting %s to %s by %s with ids: %v", file, out, delim, ids)
-		newLines, err := iom.SplitByAndPluckIDsFile(file, delim, intIds)
+		lines, style, err := iom.ReadFileStyle(file)
 		if err != nil {
 			log.Fatal(err)
 		}
 
-		concatLines := concatSplitLines(newLines, delim)
-		if err = iom.WriteFile(out, concatLines); err != nil {
+		concatLines := concatSplitLines(iom.SplitByAndPluckIDs(lines, delim, intIds), delim)
+		if err = iom.WriteFileStyle(out, concatLines, style); err != nil {
 			log.Fatal(err)
 		}
 
@@ -56,13 +56,13 @@ func splitDir(cmd *cobra.Command, dir string, delim string, ids []int) {
 			out := iom.AppendSuffixToFilename(file, "-split")
 
 			log.Printf("Spltting %s to %s", file, out)
-			newLines, err := iom.SplitByAndPluckIDsFile(file, delim, ids)
+			lines, style, err := iom.ReadFileStyle(file)
 			if err != nil {
 				log.Fatal(err)
 			}
 
-			concatLines := concatSplitLines(newLines, delim)
-			if err = iom.WriteFile(out, concatLines); err != nil {
+			concatLines := concatSplitLines(iom.SplitByAndPluckIDs(lines, delim, ids), delim)
+			if err = iom.WriteFileStyle(out, concatLines, style); err != nil {
 				log.Fatal(err)
 			}
 
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/netr/listy/iom"
+	"github.com/spf13/cobra"
+)
+
+// transformCmd represents the transform command
+var transformCmd = &cobra.Command{
+	Use:   "transform",
+	Short: "Rewrite lines of file(s) with a chain of operations",
+	Long: `Rewrite every line of file(s) by applying a chain of operations in order. Operations are given
+with repeatable --op flags or one per line in a --script file (blank lines and # comments are
+ignored); script operations run first. Arguments may be quoted with '...' or "...".
+
+  replace <regex> <replacement>            regex replace, $1 style capture groups
+  prefix <text>, suffix <text>             add text
+  strip-prefix <text>, strip-suffix <text> remove text if present
+  lower, upper, title                      case conversion
+  trim [cutset]                            trim whitespace or the given characters
+  pad-left <width> [char]                  pad to width characters
+  pad-right <width> [char]
+  truncate <width>                         cut to at most width characters
+  delim <delim>                            delimiter of following field operations (default ",")
+  fields <ids>                             reorder or select fields, e.g. 2,0,1
+  field-replace <id> <regex> <replacement> regex replace within one field`,
+	Run: func(cmd *cobra.Command, args []string) {
+		chain := transformChain(cmd)
+
+		dir := getFlag(cmd, "dir")
+		if dir != "" {
+			transformDir(cmd, dir, chain)
+			return
+		}
+
+		file := validateFlag(cmd, "file")
+		out := getFlag(cmd, "out", iom.AppendSuffixToFilename(file, "-transformed"))
+
+		log.Printf("Transforming %s to %s", file, out)
+		n, err := iom.TransformFile(file, out, chain)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.Printf("Changed %d lines", n)
+	},
+}
+
+func transformDir(cmd *cobra.Command, dir string, chain iom.TransformChain) {
+	log.Printf("Transforming directory %s\n\n", dir)
+
+	files, err := iom.ReadDir(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, file := range files {
+		file = sanitizeFilename(dir + "/" + file)
+		out := iom.AppendSuffixToFilename(file, "-transformed")
+
+		log.Printf("Transforming %s to %s", file, out)
+		n, err := iom.TransformFile(file, out, chain)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.Printf("Changed %d lines\n\n", n)
+	}
+}
+
+func transformChain(cmd *cobra.Command) iom.TransformChain {
+	var ops []string
+	if script := getFlag(cmd, "script"); script != "" {
+		lines, err := iom.ReadConfigFile(script)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ops = append(ops, lines...)
+	}
+	ops = append(ops, getFlagStrings(cmd, "op")...)
+
+	chain, err := iom.ParseTransforms(ops)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(chain) == 0 {
+		log.Fatal("Please provide a value for --op or --script")
+	}
+
+	return chain
+}
+
+func init() {
+	rootCmd.AddCommand(transformCmd)
+	transformCmd.Flags().StringP("file", "f", "", "File to transform")
+	transformCmd.Flags().StringP("dir", "d", "", "Directory to transform")
+	transformCmd.Flags().StringP("out", "o", "", "Output file")
+	transformCmd.Flags().StringArrayP("op", "e", nil, "Operation to apply, e.g. 'replace ^www\\. \"\"' (repeatable)")
+	transformCmd.Flags().StringP("script", "x", "", "File of operations, one per line")
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/netr/listy/iom"
+	"github.com/spf13/cobra"
+)
+
+// urlCmd represents the url command
+var urlCmd = &cobra.Command{
+	Use:   "url",
+	Short: "Canonicalize, dedupe and extract parts of URL file(s)",
+	Long: `Parse every line of file(s) as a URL. Lines without a scheme are treated as http URLs.
+Output default ` + "`{file}-urls`" + `
+
+The canonical form lower cases the scheme and host, removes default ports and fragments,
+resolves dot segments, strips tracking parameters (utm_*, fbclid, gclid, ...) and any --strip
+parameters, and sorts the remaining query parameters.
+
+  --canonicalize        write the canonical form instead of the original line
+  --extract host        write the host, path or param:{name} instead
+  --dedupe              keep only the first line of each canonical form`,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := iom.URLOptions{
+			Canonicalize: getFlagBool(cmd, "canonicalize"),
+			Extract:      getFlag(cmd, "extract"),
+			Dedupe:       getFlagBool(cmd, "dedupe"),
+			Strip:        getFlagStrings(cmd, "strip"),
+		}
+		rejects := getFlagBool(cmd, "rejects")
+
+		dir := getFlag(cmd, "dir")
+		if dir != "" {
+			log.Printf("Processing URLs in directory %s\n\n", dir)
+
+			files, err := iom.ReadDir(dir)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			for _, file := range files {
+				file = sanitizeFilename(dir + "/" + file)
+				urlFile(file, iom.AppendSuffixToFilename(file, "-urls"), rejects, opts)
+				log.Println()
+			}
+			return
+		}
+
+		file := validateFlag(cmd, "file")
+		urlFile(file, getFlag(cmd, "out", iom.AppendSuffixToFilename(file, "-urls")), rejects, opts)
+	},
+}
+
+func urlFile(file, out string, rejects bool, opts iom.URLOptions) {
+	rejectsOut := ""
+	if rejects {
+		rejectsOut = iom.AppendSuffixToFilename(file, "-rejected")
+	}
+
+	log.Printf("Processing URLs in %s to %s", file, out)
+	stats, err := iom.ProcessURLsFile(file, out, rejectsOut, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Wrote %d lines, removed %d duplicates", stats.Written, stats.Duplicates)
+	if stats.Missing > 0 {
+		log.Printf("Skipped %d URLs without %s", stats.Missing, opts.Extract)
+	}
+	if stats.Rejected > 0 {
+		log.Printf("Rejected %d invalid URLs", stats.Rejected)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(urlCmd)
+	urlCmd.Flags().StringP("file", "f", "", "File of URLs")
+	urlCmd.Flags().StringP("dir", "d", "", "Directory of URL files")
+	urlCmd.Flags().StringP("out", "o", "", "Output file")
+	urlCmd.Flags().BoolP("canonicalize", "c", false, "Write the canonical form of each URL")
+	urlCmd.Flags().StringP("extract", "e", "", "Write a part of each URL: host, path or param:{name}")
+	urlCmd.Flags().BoolP("dedupe", "u", false, "Keep only the first URL of each canonical form")
+	urlCmd.Flags().StringArray("strip", nil, "Additional query parameter to remove (repeatable)")
+	urlCmd.Flags().BoolP("rejects", "r", false, "Write invalid URLs to {file}-rejected")
+}
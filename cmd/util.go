@@ -59,3 +59,30 @@ func sanitizeFilename(filename string) string {
 	}
 	return strings.Trim(filename, "/")
 }
+
+func getFlagBool(cmd *cobra.Command, flag string) bool {
+	val, err := cmd.Flags().GetBool(flag)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return val
+}
+
+func getFlagFloat(cmd *cobra.Command, flag string) float64 {
+	val, err := cmd.Flags().GetFloat64(flag)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return val
+}
+
+func getFlagStrings(cmd *cobra.Command, flag string) []string {
+	val, err := cmd.Flags().GetStringArray(flag)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return val
+}
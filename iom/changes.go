@@ -0,0 +1,113 @@
+package iom
+
+import "fmt"
+
+// Changes holds the lines that differ between two versions of a list
+type Changes struct {
+	// Added are lines of the new list whose key is not in the old list
+	Added []string
+	// Removed are lines of the old list whose key is not in the new list
+	Removed []string
+	// Modified are lines of the new list whose key is in the old list, but with different fields
+	Modified []string
+	// Unchanged are lines of the new list that are also in the old list
+	Unchanged []string
+	// Skipped is the number of lines in either list without the selected key
+	Skipped int
+}
+
+// ChangesSummary is the JSON summary of the changes between two files
+type ChangesSummary struct {
+	Old       string            `json:"old"`
+	New       string            `json:"new"`
+	Added     int               `json:"added"`
+	Removed   int               `json:"removed"`
+	Modified  int               `json:"modified"`
+	Unchanged int               `json:"unchanged"`
+	Skipped   int               `json:"skipped"`
+	Files     map[string]string `json:"files"`
+}
+
+// ComputeChanges compares an old and next version of a list in both directions. Added and removed
+// lines are the DiffByKey of each version against the keys of the other. Lines are matched by key,
+// so a whole line KeySelector never reports modified lines
+func ComputeChanges(old, next []string, key KeySelector) Changes {
+	var c Changes
+
+	oldKeys := keyMap(old, key)
+	nextKeys := keyMap(next, key)
+
+	_, skippedNext, added := DiffByKey(oldKeys, next, key)
+	_, skippedOld, removed := DiffByKey(nextKeys, old, key)
+	c.Added, c.Removed = added, removed
+	c.Skipped = skippedOld + skippedNext
+
+	oldLines := make(map[string]struct{}, len(old))
+	for _, line := range old {
+		oldLines[line] = struct{}{}
+	}
+	for _, line := range next {
+		k, ok := key.Key(line)
+		if !ok {
+			continue
+		}
+		if _, ok := oldKeys[k]; !ok {
+			continue
+		}
+		if _, ok := oldLines[line]; ok {
+			c.Unchanged = append(c.Unchanged, line)
+		} else {
+			c.Modified = append(c.Modified, line)
+		}
+	}
+
+	return c
+}
+
+// ChangesFiles compares an old and new version of a list file. Each set of lines is written to
+// the new file's name with an -added, -removed, -modified or -unchanged suffix, unless it is
+// modified and key selects the whole line
+func ChangesFiles(oldFile, newFile string, key KeySelector) (ChangesSummary, error) {
+	summary := ChangesSummary{Old: oldFile, New: newFile, Files: make(map[string]string)}
+
+	old, err := ReadFile(oldFile)
+	if err != nil {
+		return summary, fmt.Errorf("changes files: %w", err)
+	}
+
+	next, style, err := ReadFileStyle(newFile)
+	if err != nil {
+		return summary, fmt.Errorf("changes files: %w", err)
+	}
+
+	c := ComputeChanges(old, next, key)
+	summary.Added = len(c.Added)
+	summary.Removed = len(c.Removed)
+	summary.Modified = len(c.Modified)
+	summary.Unchanged = len(c.Unchanged)
+	summary.Skipped = c.Skipped
+
+	sets := []struct {
+		name  string
+		lines []string
+	}{
+		{"added", c.Added},
+		{"removed", c.Removed},
+		{"modified", c.Modified},
+		{"unchanged", c.Unchanged},
+	}
+	for _, set := range sets {
+		if set.name == "modified" && key.IsWholeLine() {
+			continue
+		}
+
+		file := AppendSuffixToFilename(newFile, "-"+set.name)
+		err = WriteFileStyle(file, set.lines, style)
+		if err != nil {
+			return summary, fmt.Errorf("changes files: %w", err)
+		}
+		summary.Files[set.name] = file
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,47 @@
+package iom
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_ComputeChanges(t *testing.T) {
+	old := []string{"one", "two", "three"}
+	next := []string{"two", "three", "four"}
+
+	c := ComputeChanges(old, next, KeySelector{})
+	if !reflect.DeepEqual(c.Added, []string{"four"}) {
+		t.Errorf("ComputeChanges() added = %v", c.Added)
+	}
+	if !reflect.DeepEqual(c.Removed, []string{"one"}) {
+		t.Errorf("ComputeChanges() removed = %v", c.Removed)
+	}
+	if !reflect.DeepEqual(c.Unchanged, []string{"two", "three"}) {
+		t.Errorf("ComputeChanges() unchanged = %v", c.Unchanged)
+	}
+	if len(c.Modified) != 0 {
+		t.Errorf("ComputeChanges() modified = %v", c.Modified)
+	}
+}
+
+func Test_ComputeChanges_Key(t *testing.T) {
+	old := []string{"Ann,a@x.com", "Bob,b@x.com", "Cid,c@x.com"}
+	next := []string{"Ann,a@x.com", "Robert,b@x.com", "Dee,d@x.com", "broken"}
+
+	c := ComputeChanges(old, next, KeySelector{Delim: ",", IDs: []int{1}})
+	if !reflect.DeepEqual(c.Added, []string{"Dee,d@x.com"}) {
+		t.Errorf("ComputeChanges() added = %v", c.Added)
+	}
+	if !reflect.DeepEqual(c.Removed, []string{"Cid,c@x.com"}) {
+		t.Errorf("ComputeChanges() removed = %v", c.Removed)
+	}
+	if !reflect.DeepEqual(c.Modified, []string{"Robert,b@x.com"}) {
+		t.Errorf("ComputeChanges() modified = %v", c.Modified)
+	}
+	if !reflect.DeepEqual(c.Unchanged, []string{"Ann,a@x.com"}) {
+		t.Errorf("ComputeChanges() unchanged = %v", c.Unchanged)
+	}
+	if c.Skipped != 1 {
+		t.Errorf("ComputeChanges() skipped = %v, want 1", c.Skipped)
+	}
+}
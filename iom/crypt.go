@@ -0,0 +1,399 @@
+package iom
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Encrypted files are one or more streams, so encrypted lines can be appended to a file. A stream
+// is a header followed by chunks of at most cryptChunkSize plaintext bytes, each sealed with
+// AES-256-GCM under the nonce prefix, the chunk counter and a flag marking the last chunk, so
+// reordered, truncated or extended streams fail to decrypt. Every chunk authenticates the header.
+// Authentication does not extend across streams: whole streams of a multi-stream file can be
+// removed, reordered or duplicated without detection, and every stream adds a header, so files
+// that must be tamper proof as a whole should be written in one run rather than appended to
+//
+//	magic    8 bytes  "LISTYENC"
+//	version  1 byte
+//	kdf      1 byte   cryptKDFArgon2 or cryptKDFKeyfile
+//	time     4 bytes  argon2id parameters, zero for keyfiles
+//	memory   4 bytes
+//	threads  1 byte
+//	salt     16 bytes
+//	nonce    7 bytes  random nonce prefix
+//
+// A chunk is its sealed length as 4 bytes followed by the sealed bytes
+const (
+	cryptMagic      = "LISTYENC"
+	cryptVersion    = 1
+	cryptHeaderSize = 42
+	cryptChunkSize  = 64 * 1024
+
+	cryptKDFArgon2  = 1
+	cryptKDFKeyfile = 2
+
+	argonTime    = 3
+	argonMemory  = 64 * 1024
+	argonThreads = 4
+
+	// headers are read from untrusted files, so their argon2id parameters are capped to bound the
+	// time and memory spent deriving a key
+	argonMaxTime    = 4 * argonTime
+	argonMaxMemory  = 4 * argonMemory
+	argonMaxThreads = 4 * argonThreads
+)
+
+// Encryption holds the passphrase or keyfile used to encrypt and decrypt files. Derived keys are
+// cached, so a passphrase is only stretched once per salt
+type Encryption struct {
+	kdf    byte
+	secret []byte
+
+	mu   sync.Mutex
+	keys map[string]cipher.AEAD
+	salt []byte
+}
+
+// NewPassphraseEncryption returns an Encryption deriving keys from a passphrase with argon2id
+func NewPassphraseEncryption(passphrase []byte) (*Encryption, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("new passphrase encryption: empty passphrase")
+	}
+	return &Encryption{kdf: cryptKDFArgon2, secret: passphrase}, nil
+}
+
+// NewKeyfileEncryption returns an Encryption deriving keys from the contents of a keyfile, which
+// must hold at least 32 bytes, e.g. from head -c 32 /dev/urandom
+func NewKeyfileEncryption(key []byte) (*Encryption, error) {
+	if len(key) < 32 {
+		return nil, errors.New("new keyfile encryption: keyfile must hold at least 32 bytes")
+	}
+	return &Encryption{kdf: cryptKDFKeyfile, secret: key}, nil
+}
+
+// ReadKeyfile returns an Encryption using the contents of a keyfile
+func ReadKeyfile(file string) (*Encryption, error) {
+	key, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read keyfile: %w", err)
+	}
+	return NewKeyfileEncryption(key)
+}
+
+// header returns a new stream header and its AEAD. Streams written by one Encryption share a salt,
+// so the key is only derived once
+func (e *Encryption) header() ([]byte, cipher.AEAD, error) {
+	e.mu.Lock()
+	if e.salt == nil {
+		e.salt = make([]byte, 16)
+		if _, err := rand.Read(e.salt); err != nil {
+			e.mu.Unlock()
+			return nil, nil, err
+		}
+	}
+	salt := e.salt
+	e.mu.Unlock()
+
+	h := make([]byte, 0, cryptHeaderSize)
+	h = append(h, cryptMagic...)
+	h = append(h, cryptVersion, e.kdf)
+	if e.kdf == cryptKDFArgon2 {
+		h = binary.BigEndian.AppendUint32(h, argonTime)
+		h = binary.BigEndian.AppendUint32(h, argonMemory)
+		h = append(h, argonThreads)
+	} else {
+		h = append(h, make([]byte, 9)...)
+	}
+	h = append(h, salt...)
+
+	prefix := make([]byte, 7)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, nil, err
+	}
+	h = append(h, prefix...)
+
+	aead, err := e.aead(h)
+	if err != nil {
+		return nil, nil, err
+	}
+	return h, aead, nil
+}
+
+// aead returns the AEAD for a stream header, deriving its key if it is not cached
+func (e *Encryption) aead(h []byte) (cipher.AEAD, error) {
+	if h[8] != cryptVersion {
+		return nil, fmt.Errorf("unsupported encryption version %d", h[8])
+	}
+	if h[9] != e.kdf {
+		if h[9] == cryptKDFArgon2 {
+			return nil, errors.New("file was encrypted with a passphrase, not a keyfile")
+		}
+		return nil, errors.New("file was encrypted with a keyfile, not a passphrase")
+	}
+
+	params := string(h[9:35])
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if a, ok := e.keys[params]; ok {
+		return a, nil
+	}
+
+	salt := h[19:35]
+	var key []byte
+	switch e.kdf {
+	case cryptKDFArgon2:
+		time := binary.BigEndian.Uint32(h[10:14])
+		memory := binary.BigEndian.Uint32(h[14:18])
+		if time == 0 || memory == 0 || h[18] == 0 {
+			return nil, errors.New("invalid argon2id parameters")
+		}
+		if time > argonMaxTime || memory > argonMaxMemory || h[18] > argonMaxThreads {
+			return nil, fmt.Errorf("argon2id parameters time=%d memory=%dKiB threads=%d exceed the maximum", time, memory, h[18])
+		}
+		key = argon2.IDKey(e.secret, salt, time, memory, h[18], 32)
+	default:
+		key = make([]byte, 32)
+		if _, err := io.ReadFull(hkdf.New(sha256.New, e.secret, salt, []byte("listy keyfile")), key); err != nil {
+			return nil, err
+		}
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	a, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.keys == nil {
+		e.keys = make(map[string]cipher.AEAD)
+	}
+	e.keys[params] = a
+	return a, nil
+}
+
+func chunkNonce(h []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, h[35:42])
+	binary.BigEndian.PutUint32(nonce[7:11], counter)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// encryptWriter encrypts one stream. Chunks are only sealed once more data follows them, so
+// Close can mark the last one
+type encryptWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	header  []byte
+	counter uint32
+	buf     []byte
+}
+
+func newEncryptWriter(w io.Writer, e *Encryption) (*encryptWriter, error) {
+	h, aead, err := e.header()
+	if err != nil {
+		return nil, fmt.Errorf("encrypt: %w", err)
+	}
+	if _, err := w.Write(h); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{w: w, aead: aead, header: h}, nil
+}
+
+func (w *encryptWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for len(w.buf) > cryptChunkSize {
+		if err := w.seal(w.buf[:cryptChunkSize], false); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[cryptChunkSize:]
+	}
+	return len(p), nil
+}
+
+func (w *encryptWriter) seal(chunk []byte, last bool) error {
+	if w.counter == ^uint32(0) {
+		return errors.New("encrypt: stream too long")
+	}
+	sealed := w.aead.Seal(nil, chunkNonce(w.header, w.counter, last), chunk, w.header)
+	w.counter++
+
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(len(sealed)))
+	if _, err := w.w.Write(n[:]); err != nil {
+		return err
+	}
+	_, err := w.w.Write(sealed)
+	return err
+}
+
+// Close seals the last chunk. It does not close the underlying writer
+func (w *encryptWriter) Close() error {
+	err := w.seal(w.buf, true)
+	w.buf = nil
+	return err
+}
+
+// decryptReader decrypts the streams of an encrypted file
+type decryptReader struct {
+	r       *bufio.Reader
+	e       *Encryption
+	file    string
+	aead    cipher.AEAD
+	header  []byte
+	counter uint32
+	done    bool
+	plain   []byte
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if err := d.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// next decrypts the next chunk, starting a new stream after the last chunk of the previous one
+func (d *decryptReader) next() error {
+	if d.aead == nil || d.done {
+		h := make([]byte, cryptHeaderSize)
+		if _, err := io.ReadFull(d.r, h); err != nil {
+			if err == io.EOF && d.aead != nil {
+				return io.EOF
+			}
+			return fmt.Errorf("decrypt %s: truncated header", d.file)
+		}
+		if string(h[:8]) != cryptMagic {
+			return fmt.Errorf("decrypt %s: unexpected data after an encrypted stream", d.file)
+		}
+		aead, err := d.e.aead(h)
+		if err != nil {
+			return fmt.Errorf("decrypt %s: %w", d.file, err)
+		}
+		d.aead, d.header, d.counter, d.done = aead, h, 0, false
+	}
+
+	var n [4]byte
+	if _, err := io.ReadFull(d.r, n[:]); err != nil {
+		return fmt.Errorf("decrypt %s: truncated file", d.file)
+	}
+	size := binary.BigEndian.Uint32(n[:])
+	if size < uint32(d.aead.Overhead()) || size > cryptChunkSize+uint32(d.aead.Overhead()) {
+		return fmt.Errorf("decrypt %s: invalid chunk length", d.file)
+	}
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(d.r, sealed); err != nil {
+		return fmt.Errorf("decrypt %s: truncated file", d.file)
+	}
+
+	plain, err := d.aead.Open(nil, chunkNonce(d.header, d.counter, false), sealed, d.header)
+	if err != nil {
+		plain, err = d.aead.Open(nil, chunkNonce(d.header, d.counter, true), sealed, d.header)
+		if err != nil {
+			return fmt.Errorf("decrypt %s: wrong passphrase or keyfile, or the file is corrupted", d.file)
+		}
+		d.done = true
+	}
+	d.counter++
+	d.plain = plain
+	return nil
+}
+
+// isEncrypted reports whether r starts with an encrypted stream header
+func isEncrypted(r *bufio.Reader) bool {
+	magic, _ := r.Peek(len(cryptMagic))
+	return bytes.Equal(magic, []byte(cryptMagic))
+}
+
+// IsEncryptedFile reports whether a file is encrypted
+func IsEncryptedFile(file string) (bool, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	return isEncrypted(bufio.NewReader(f)), nil
+}
+
+// decryptInput returns a buffered reader of the plaintext of f, decrypting it if it is encrypted
+func decryptInput(file string, f io.Reader) (*bufio.Reader, error) {
+	r := bufio.NewReaderSize(f, sniffSize)
+	if !isEncrypted(r) {
+		return r, nil
+	}
+
+	e := fileOptions.Encryption
+	if e == nil {
+		return nil, fmt.Errorf("%s is encrypted: a passphrase or keyfile is required", file)
+	}
+	return bufio.NewReaderSize(&decryptReader{r: r, e: e, file: file}, sniffSize), nil
+}
+
+// EncryptFile writes an encrypted copy of src to dst, decrypting src first if it is encrypted
+func EncryptFile(src, dst string, e *Encryption) error {
+	return cryptFile(src, dst, e)
+}
+
+// DecryptFile writes a plaintext copy of src to dst
+func DecryptFile(src, dst string) error {
+	return cryptFile(src, dst, nil)
+}
+
+func cryptFile(src, dst string, e *Encryption) error {
+	r, in, err := openRaw(src)
+	if err != nil {
+		return fmt.Errorf("crypt file: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("crypt file: %w", err)
+	}
+	defer out.Close()
+
+	bw := bufio.NewWriter(out)
+	var w io.Writer = bw
+	var enc *encryptWriter
+	if e != nil {
+		if enc, err = newEncryptWriter(bw, e); err != nil {
+			return fmt.Errorf("crypt file: %w", err)
+		}
+		w = enc
+	}
+
+	if _, err = io.Copy(w, r); err != nil {
+		return fmt.Errorf("crypt file: %w", err)
+	}
+	if enc != nil {
+		if err = enc.Close(); err != nil {
+			return fmt.Errorf("crypt file: %w", err)
+		}
+	}
+	if err = bw.Flush(); err != nil {
+		return fmt.Errorf("crypt file: %w", err)
+	}
+	return out.Close()
+}
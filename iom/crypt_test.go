@@ -0,0 +1,165 @@
+package iom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testKeyfileEncryption(t *testing.T, key string) *Encryption {
+	t.Helper()
+	e, err := NewKeyfileEncryption([]byte(strings.Repeat(key, 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func setTestFileOptions(t *testing.T, opts FileOptions) {
+	t.Helper()
+	if err := SetFileOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetFileOptions(FileOptions{}) })
+}
+
+func Test_EncryptedRoundTrip(t *testing.T) {
+	e := testKeyfileEncryption(t, "k")
+	setTestFileOptions(t, FileOptions{Encrypt: true, Encryption: e})
+
+	// sizes around the chunk size, including an exact multiple of it
+	for _, size := range []int{0, 10, cryptChunkSize - 1, cryptChunkSize, 3*cryptChunkSize + 7} {
+		var lines []string
+		for n := 0; n < size; n += 16 {
+			lines = append(lines, strings.Repeat("x", 15))
+		}
+
+		file := filepath.Join(t.TempDir(), "list.txt")
+		if err := WriteFile(file, lines); err != nil {
+			t.Fatal(err)
+		}
+
+		raw, _ := os.ReadFile(file)
+		if !bytes.HasPrefix(raw, []byte(cryptMagic)) || (len(lines) > 0 && bytes.Contains(raw, []byte(lines[0]))) {
+			t.Fatalf("size %d: file is not encrypted", size)
+		}
+
+		got, err := ReadFile(file)
+		if err != nil {
+			t.Fatalf("size %d: ReadFile() error = %v", size, err)
+		}
+		if len(got) != len(lines) || (len(lines) > 0 && !reflect.DeepEqual(got, lines)) {
+			t.Errorf("size %d: ReadFile() read %d lines, want %d", size, len(got), len(lines))
+		}
+	}
+}
+
+func Test_EncryptedAppend(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "list.txt")
+	setTestFileOptions(t, FileOptions{Encrypt: true, Encryption: testKeyfileEncryption(t, "k")})
+
+	for _, line := range []string{"a", "b", "c"} {
+		if err := AppendFile(file, []string{line}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := ReadFile(file)
+	if err != nil || !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("ReadFile() = %v, %v", got, err)
+	}
+
+	SetFileOptions(FileOptions{Encryption: GetFileOptions().Encryption})
+	if err := AppendFile(file, []string{"plain"}); err == nil {
+		t.Error("AppendFile() expected error appending plaintext to an encrypted file")
+	}
+}
+
+func Test_DecryptErrors(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "list.txt")
+	setTestFileOptions(t, FileOptions{Encrypt: true, Encryption: testKeyfileEncryption(t, "k")})
+	if err := WriteFile(file, []string{"secret", "lines"}); err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := os.ReadFile(file)
+
+	SetFileOptions(FileOptions{})
+	if _, err := ReadFile(file); err == nil || !strings.Contains(err.Error(), "passphrase or keyfile is required") {
+		t.Errorf("ReadFile() without a key error = %v", err)
+	}
+
+	SetFileOptions(FileOptions{Encryption: testKeyfileEncryption(t, "x")})
+	if _, err := ReadFile(file); err == nil {
+		t.Error("ReadFile() expected error with the wrong key")
+	}
+
+	SetFileOptions(FileOptions{Encryption: testKeyfileEncryption(t, "k")})
+	broken := map[string][]byte{
+		"truncated": raw[:len(raw)-1],
+		"no chunks": raw[:cryptHeaderSize],
+		"tampered":  append(append([]byte{}, raw[:len(raw)-1]...), raw[len(raw)-1]^1),
+		"trailing":  append(append([]byte{}, raw...), "junk"...),
+	}
+	for name, b := range broken {
+		if err := os.WriteFile(file, b, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadFile(file); err == nil {
+			t.Errorf("ReadFile() expected error for %s file", name)
+		}
+	}
+}
+
+func Test_PassphraseEncryptDecryptFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "list.txt")
+	enc := filepath.Join(dir, "list.enc")
+	dst := filepath.Join(dir, "list-plain.txt")
+	if err := os.WriteFile(src, []byte("a\nb\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := NewPassphraseEncryption([]byte("correct horse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := EncryptFile(src, enc, e); err != nil {
+		t.Fatal(err)
+	}
+
+	setTestFileOptions(t, FileOptions{Encryption: testKeyfileEncryption(t, "k")})
+	if err := DecryptFile(enc, dst); err == nil || !strings.Contains(err.Error(), "passphrase") {
+		t.Errorf("DecryptFile() with a keyfile error = %v", err)
+	}
+
+	SetFileOptions(FileOptions{Encryption: e})
+	if err := DecryptFile(enc, dst); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(dst); string(got) != "a\nb\n" {
+		t.Errorf("DecryptFile() = %q", got)
+	}
+}
+
+func Test_DecryptRejectsCostlyArgon2Params(t *testing.T) {
+	e, err := NewPassphraseEncryption([]byte("pw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := make([]byte, cryptHeaderSize)
+	copy(h, cryptMagic)
+	h[8], h[9] = cryptVersion, cryptKDFArgon2
+	binary.BigEndian.PutUint32(h[10:14], argonTime)
+	binary.BigEndian.PutUint32(h[14:18], 0xFFFFFFFF)
+	h[18] = argonThreads
+
+	if _, err := e.aead(h); err == nil || !strings.Contains(err.Error(), "exceed the maximum") {
+		t.Errorf("aead() error = %v, want parameters exceeding the maximum", err)
+	}
+}
@@ -0,0 +1,178 @@
+package iom
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+// NormalizeDomain trims, lower cases and removes the trailing dot of a domain
+func NormalizeDomain(s string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), ".")
+}
+
+// DomainToASCII converts an internationalized domain to punycode
+func DomainToASCII(host string) (string, error) {
+	return idna.Lookup.ToASCII(host)
+}
+
+// DomainToUnicode converts a punycode domain to unicode
+func DomainToUnicode(host string) (string, error) {
+	return idna.Lookup.ToUnicode(host)
+}
+
+// RegistrableDomain returns the registrable domain (eTLD+1) of a host using the embedded public
+// suffix list, e.g. www.example.co.uk is example.co.uk
+func RegistrableDomain(host string) (string, error) {
+	ascii, err := DomainToASCII(host)
+	if err != nil {
+		return "", err
+	}
+	apex, err := publicsuffix.EffectiveTLDPlusOne(ascii)
+	if err != nil {
+		return "", err
+	}
+	if ascii != host {
+		return DomainToUnicode(apex)
+	}
+	return apex, nil
+}
+
+// ReverseLabels reverses the labels of a domain, so www.example.com becomes com.example.www
+func ReverseLabels(host string) string {
+	labels := strings.Split(host, ".")
+	for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+		labels[i], labels[j] = labels[j], labels[i]
+	}
+	return strings.Join(labels, ".")
+}
+
+// SortDomains sorts domains by their reversed labels so that related hosts cluster together
+func SortDomains(hosts []string) {
+	keys := make(map[string]string, len(hosts))
+	for _, h := range hosts {
+		keys[h] = ReverseLabels(h)
+	}
+	sort.SliceStable(hosts, func(i, j int) bool { return keys[hosts[i]] < keys[hosts[j]] })
+}
+
+// GroupByApex groups hosts under their registrable domain and returns one line per apex: the apex,
+// a tab and its hosts joined by commas. Apexes and hosts are sorted by reversed labels
+func GroupByApex(hosts []string) ([]string, error) {
+	groups := make(map[string][]string)
+	var apexes []string
+	for _, h := range hosts {
+		apex, err := RegistrableDomain(h)
+		if err != nil {
+			return nil, fmt.Errorf("group by apex: %s: %w", h, err)
+		}
+		if _, ok := groups[apex]; !ok {
+			apexes = append(apexes, apex)
+		}
+		groups[apex] = append(groups[apex], h)
+	}
+
+	SortDomains(apexes)
+	result := make([]string, len(apexes))
+	for i, apex := range apexes {
+		SortDomains(groups[apex])
+		result[i] = apex + "\t" + strings.Join(groups[apex], ",")
+	}
+	return result, nil
+}
+
+// DomainOptions configures ProcessDomains. Steps run in the order of the fields
+type DomainOptions struct {
+	ToASCII     bool
+	Validate    bool
+	Registrable bool
+	ToUnicode   bool
+	Dedupe      bool
+	Sort        bool
+	Group       bool
+}
+
+// ProcessDomains normalizes every line as a domain and applies opts. It returns the output lines
+// and the rejected lines, each followed by a tab and the reason
+func ProcessDomains(lines []string, opts DomainOptions) ([]string, []string, error) {
+	var result, rejected []string
+	for _, line := range lines {
+		host := NormalizeDomain(line)
+		if host == "" {
+			continue
+		}
+
+		var err error
+		if opts.ToASCII {
+			host, err = DomainToASCII(host)
+		}
+		if err == nil && opts.Validate {
+			var ascii string
+			if ascii, err = DomainToASCII(host); err == nil {
+				err = ValidateHostname(ascii, false)
+			}
+		}
+		if err == nil && opts.Registrable {
+			host, err = RegistrableDomain(host)
+		}
+		if err == nil && opts.ToUnicode {
+			host, err = DomainToUnicode(host)
+		}
+		if err == nil && opts.Group {
+			// hosts without a registrable domain, such as localhost, cannot be grouped
+			_, err = RegistrableDomain(host)
+		}
+		if err != nil {
+			rejected = append(rejected, line+"\t"+err.Error())
+			continue
+		}
+
+		result = append(result, host)
+	}
+
+	if opts.Dedupe {
+		_, result = RemoveDuplicates(result)
+	}
+	if opts.Sort {
+		SortDomains(result)
+	}
+	if opts.Group {
+		var err error
+		if result, err = GroupByApex(result); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return result, rejected, nil
+}
+
+// ProcessDomainsFile processes the domains of src into dst, writing rejected lines to rejects
+// unless it is empty. It returns the number of written and rejected lines
+func ProcessDomainsFile(src, dst, rejects string, opts DomainOptions) (int, int, error) {
+	lines, style, err := ReadFileStyle(src)
+	if err != nil {
+		return 0, 0, fmt.Errorf("process domains file: %w", err)
+	}
+
+	result, rejected, err := ProcessDomains(lines, opts)
+	if err != nil {
+		return 0, 0, fmt.Errorf("process domains file: %w", err)
+	}
+
+	err = WriteFileStyle(dst, result, style)
+	if err != nil {
+		return 0, 0, fmt.Errorf("process domains file: %w", err)
+	}
+
+	if rejects != "" {
+		err = WriteFileStyle(rejects, rejected, style)
+		if err != nil {
+			return 0, 0, fmt.Errorf("process domains file: %w", err)
+		}
+	}
+
+	return len(result), len(rejected), nil
+}
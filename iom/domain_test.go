@@ -0,0 +1,88 @@
+package iom
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_RegistrableDomain(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"www.example.com", "example.com"},
+		{"a.b.example.co.uk", "example.co.uk"},
+		{"example.com", "example.com"},
+		{"shop.bücher.de", "bücher.de"},
+	}
+
+	for _, tt := range tests {
+		got, err := RegistrableDomain(tt.in)
+		if err != nil {
+			t.Fatalf("RegistrableDomain(%q) error = %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Errorf("RegistrableDomain(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	if _, err := RegistrableDomain("co.uk"); err == nil {
+		t.Error("RegistrableDomain(co.uk) expected error for a public suffix")
+	}
+}
+
+func Test_DomainPunycode(t *testing.T) {
+	ascii, err := DomainToASCII("bücher.de")
+	if err != nil || ascii != "xn--bcher-kva.de" {
+		t.Errorf("DomainToASCII() = %q, %v", ascii, err)
+	}
+
+	unicode, err := DomainToUnicode(ascii)
+	if err != nil || unicode != "bücher.de" {
+		t.Errorf("DomainToUnicode() = %q, %v", unicode, err)
+	}
+}
+
+func Test_SortDomains(t *testing.T) {
+	hosts := []string{"www.example.com", "a.org", "example.com", "api.example.com", "b.net"}
+	SortDomains(hosts)
+
+	want := []string{"example.com", "api.example.com", "www.example.com", "b.net", "a.org"}
+	if !reflect.DeepEqual(hosts, want) {
+		t.Errorf("SortDomains() = %v, want %v", hosts, want)
+	}
+}
+
+func Test_ProcessDomains(t *testing.T) {
+	lines := []string{"WWW.Example.com.", "api.example.com", "bad_host.com", "x.example.co.uk"}
+
+	got, rejected, err := ProcessDomains(lines, DomainOptions{Validate: true, Group: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"example.com\tapi.example.com,www.example.com", "example.co.uk\tx.example.co.uk"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ProcessDomains() = %q, want %q", got, want)
+	}
+	if len(rejected) != 1 {
+		t.Errorf("ProcessDomains() rejected = %q", rejected)
+	}
+}
+
+func Test_ProcessDomains_GroupWithoutApex(t *testing.T) {
+	lines := []string{"localhost", "www.example.com", "com"}
+
+	got, rejected, err := ProcessDomains(lines, DomainOptions{Group: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"example.com\twww.example.com"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ProcessDomains() = %q, want %q", got, want)
+	}
+	if len(rejected) != 2 || !strings.HasPrefix(rejected[0], "localhost\t") {
+		t.Errorf("ProcessDomains() rejected = %q", rejected)
+	}
+}
@@ -0,0 +1,77 @@
+package iom
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Position is the location of a line within a file. Line numbers start at 1
+type Position struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// String returns the position as file:line
+func (p Position) String() string {
+	return p.File + ":" + strconv.Itoa(p.Line)
+}
+
+// Duplicate is a line that occurs more than once, along with every position it occurs at
+type Duplicate struct {
+	Line      string     `json:"line"`
+	Count     int        `json:"count"`
+	Positions []Position `json:"positions"`
+}
+
+// String returns the duplicate as a tab separated count, line and list of positions
+func (d Duplicate) String() string {
+	positions := make([]string, len(d.Positions))
+	for i, p := range d.Positions {
+		positions[i] = p.String()
+	}
+	return strconv.Itoa(d.Count) + "\t" + d.Line + "\t" + strings.Join(positions, " ")
+}
+
+// FindDuplicates returns every line that occurs more than once across the given files, in order
+// of first occurrence. Lines are compared the same way as RemoveDuplicates, so every line reported
+// here, apart from its first occurrence, is one RemoveDuplicates would remove
+func FindDuplicates(files []string, lines [][]string) []Duplicate {
+	var found []*Duplicate
+	seen := make(lineIndex)
+
+	for i, s := range lines {
+		for n, v := range s {
+			pos := Position{File: files[i], Line: n + 1}
+			if j, dup := seen.first(v, len(found)); dup {
+				found[j].Count++
+				found[j].Positions = append(found[j].Positions, pos)
+				continue
+			}
+			found = append(found, &Duplicate{Line: v, Count: 1, Positions: []Position{pos}})
+		}
+	}
+
+	result := []Duplicate{}
+	for _, d := range found {
+		if d.Count > 1 {
+			result = append(result, *d)
+		}
+	}
+
+	return result
+}
+
+// FindDuplicatesFiles returns every line that occurs more than once across the given files
+func FindDuplicatesFiles(files []string) ([]Duplicate, error) {
+	var lines [][]string
+	for _, file := range files {
+		l, err := ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("find duplicates files: %w", err)
+		}
+		lines = append(lines, l)
+	}
+
+	return FindDuplicates(files, lines), nil
+}
@@ -0,0 +1,35 @@
+package iom
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_FindDuplicates(t *testing.T) {
+	files := []string{"a.txt", "b.txt"}
+	lines := [][]string{
+		{"one", "two", "one"},
+		{"three", "one", "two", "four"},
+	}
+
+	got := FindDuplicates(files, lines)
+	want := []Duplicate{
+		{Line: "one", Count: 3, Positions: []Position{{"a.txt", 1}, {"a.txt", 3}, {"b.txt", 2}}},
+		{Line: "two", Count: 2, Positions: []Position{{"a.txt", 2}, {"b.txt", 3}}},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindDuplicates() = %v, want %v", got, want)
+	}
+
+	if got[0].String() != "3\tone\ta.txt:1 a.txt:3 b.txt:2" {
+		t.Errorf("Duplicate.String() = %q", got[0].String())
+	}
+}
+
+func Test_FindDuplicates_None(t *testing.T) {
+	got := FindDuplicates([]string{"a.txt"}, [][]string{{"one", "two"}})
+	if got == nil || len(got) != 0 {
+		t.Errorf("FindDuplicates() = %#v, want an empty slice", got)
+	}
+}
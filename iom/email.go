@@ -0,0 +1,363 @@
+package iom
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+//go:embed data/disposable_domains.txt
+var embeddedDisposableDomains string
+
+// EmailProvider describes how a mail provider canonicalizes local parts
+type EmailProvider struct {
+	// StripDots removes dots from the local part, as gmail ignores them
+	StripDots bool
+	// StripPlus removes a +tag from the local part
+	StripPlus bool
+	// Domain replaces the domain, e.g. googlemail.com is gmail.com
+	Domain string
+}
+
+// DefaultEmailProviders returns the canonicalization rules of common providers
+func DefaultEmailProviders() map[string]EmailProvider {
+	return map[string]EmailProvider{
+		"gmail.com":      {StripDots: true, StripPlus: true},
+		"googlemail.com": {StripDots: true, StripPlus: true, Domain: "gmail.com"},
+		"outlook.com":    {StripPlus: true},
+		"hotmail.com":    {StripPlus: true},
+		"live.com":       {StripPlus: true},
+		"icloud.com":     {StripPlus: true},
+		"me.com":         {StripPlus: true},
+		"fastmail.com":   {StripPlus: true},
+		"protonmail.com": {StripPlus: true},
+		"proton.me":      {StripPlus: true},
+	}
+}
+
+// ParseEmailProvider parses a provider rule such as "example.com=dots,plus" or
+// "alias.com=plus,domain:example.com"
+func ParseEmailProvider(s string) (string, EmailProvider, error) {
+	domain, rules, ok := strings.Cut(s, "=")
+	if !ok || domain == "" {
+		return "", EmailProvider{}, fmt.Errorf("parse email provider: %q must be domain=rules", s)
+	}
+
+	var p EmailProvider
+	for _, rule := range strings.Split(rules, ",") {
+		switch {
+		case rule == "dots":
+			p.StripDots = true
+		case rule == "plus":
+			p.StripPlus = true
+		case strings.HasPrefix(rule, "domain:"):
+			p.Domain = strings.ToLower(strings.TrimPrefix(rule, "domain:"))
+		case rule == "" || rule == "none":
+		default:
+			return "", EmailProvider{}, fmt.Errorf("parse email provider: unknown rule %q", rule)
+		}
+	}
+
+	return strings.ToLower(domain), p, nil
+}
+
+// emailTypos maps common misspellings of popular domains to the intended domain
+var emailTypos = map[string]string{
+	"gmial.com": "gmail.com", "gmai.com": "gmail.com", "gamil.com": "gmail.com", "gnail.com": "gmail.com",
+	"gmaill.com": "gmail.com", "gmail.co": "gmail.com", "gmail.cm": "gmail.com", "gmail.con": "gmail.com",
+	"gmail.cmo": "gmail.com", "gmail.ocm": "gmail.com", "gmail.comm": "gmail.com", "gmali.com": "gmail.com",
+	"gmal.com": "gmail.com", "gmil.com": "gmail.com", "gmaul.com": "gmail.com", "g-mail.com": "gmail.com",
+	"hotmial.com": "hotmail.com", "hotmai.com": "hotmail.com", "hotmal.com": "hotmail.com",
+	"hotmil.com": "hotmail.com", "hotmail.co": "hotmail.com", "hotmail.con": "hotmail.com",
+	"hotamil.com": "hotmail.com", "hormail.com": "hotmail.com",
+	"yaho.com": "yahoo.com", "yahooo.com": "yahoo.com", "yhoo.com": "yahoo.com", "yahoo.co": "yahoo.com",
+	"yahoo.con": "yahoo.com", "yaoo.com": "yahoo.com", "tahoo.com": "yahoo.com",
+	"outlok.com": "outlook.com", "outloo.com": "outlook.com", "outlook.co": "outlook.com",
+	"outlook.con": "outlook.com", "oulook.com": "outlook.com",
+	"iclod.com": "icloud.com", "icoud.com": "icloud.com", "icloud.co": "icloud.com", "icloud.con": "icloud.com",
+	"aol.co": "aol.com", "aol.con": "aol.com",
+}
+
+// roleAccounts are local parts that address a role rather than a person
+var roleAccounts = map[string]struct{}{
+	"abuse": {}, "admin": {}, "administrator": {}, "billing": {}, "careers": {}, "contact": {},
+	"enquiries": {}, "help": {}, "hello": {}, "hostmaster": {}, "info": {}, "jobs": {}, "mail": {},
+	"marketing": {}, "newsletter": {}, "no-reply": {}, "noreply": {}, "office": {}, "postmaster": {},
+	"privacy": {}, "root": {}, "sales": {}, "security": {}, "support": {}, "team": {}, "webmaster": {},
+}
+
+const emailLocalChars = "!#$%&'*+/=?^_`{|}~-."
+
+// ValidateEmail checks an address against a practical subset of RFC 5322: a dot-atom local part of
+// at most 64 characters and a domain of at least two valid labels with an alphabetic TLD. Quoted
+// local parts, comments and IP literals are not accepted
+func ValidateEmail(email string) error {
+	if len(email) > 254 {
+		return errors.New("address longer than 254 characters")
+	}
+
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return errors.New("missing @")
+	}
+	local, domain := email[:at], email[at+1:]
+
+	switch {
+	case local == "":
+		return errors.New("empty local part")
+	case len(local) > 64:
+		return errors.New("local part longer than 64 characters")
+	case strings.HasPrefix(local, ".") || strings.HasSuffix(local, ".") || strings.Contains(local, ".."):
+		return errors.New("misplaced dot in local part")
+	}
+	for _, r := range local {
+		if !isAlnum(r) && !strings.ContainsRune(emailLocalChars, r) {
+			return fmt.Errorf("invalid character %q in local part", r)
+		}
+	}
+
+	return ValidateHostname(domain, true)
+}
+
+// ValidateHostname checks that a hostname has labels of letters, digits and inner hyphens of at
+// most 63 characters each. With requireTLD, it must have at least two labels and an alphabetic or
+// punycode TLD
+func ValidateHostname(host string, requireTLD bool) error {
+	if host == "" {
+		return errors.New("empty domain")
+	}
+	if len(host) > 253 {
+		return errors.New("domain longer than 253 characters")
+	}
+
+	labels := strings.Split(host, ".")
+	for _, label := range labels {
+		switch {
+		case label == "":
+			return errors.New("empty domain label")
+		case len(label) > 63:
+			return errors.New("domain label longer than 63 characters")
+		case label[0] == '-' || label[len(label)-1] == '-':
+			return errors.New("domain label starts or ends with a hyphen")
+		}
+		for _, r := range label {
+			if !isAlnum(r) && r != '-' {
+				return fmt.Errorf("invalid character %q in domain", r)
+			}
+		}
+	}
+
+	if requireTLD {
+		if len(labels) < 2 {
+			return errors.New("domain has no TLD")
+		}
+		tld := labels[len(labels)-1]
+		if !strings.HasPrefix(strings.ToLower(tld), "xn--") {
+			if len(tld) < 2 {
+				return errors.New("TLD shorter than 2 characters")
+			}
+			for _, r := range tld {
+				if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+					return errors.New("TLD is not alphabetic")
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func isAlnum(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
+
+// ParseDisposableDomains parses a list of domains, one per line, ignoring blank lines and lines
+// starting with '#'
+func ParseDisposableDomains(lines []string) map[string]struct{} {
+	m := make(map[string]struct{})
+	for _, line := range lines {
+		line = strings.ToLower(strings.TrimSpace(line))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		m[line] = struct{}{}
+	}
+	return m
+}
+
+// DisposableDomains returns the embedded list of disposable email domains
+func DisposableDomains() map[string]struct{} {
+	return ParseDisposableDomains(strings.Split(embeddedDisposableDomains, "\n"))
+}
+
+// EmailOptions configures CleanEmail
+type EmailOptions struct {
+	// FixTypos corrects common misspellings of popular domains
+	FixTypos bool
+	// Canonicalize applies the rules of Providers for the address's domain
+	Canonicalize bool
+	Providers    map[string]EmailProvider
+	// RejectRole rejects role accounts such as admin@ and info@
+	RejectRole bool
+	// RejectDisposable rejects addresses at a domain in Disposable, or a subdomain of one
+	RejectDisposable bool
+	Disposable       map[string]struct{}
+}
+
+// EmailCheck is the result of checking one address
+type EmailCheck struct {
+	Email      string
+	Err        error
+	Typo       string
+	Role       bool
+	Disposable bool
+}
+
+// Flags returns the check as comma separated flags: "invalid: reason", "typo:domain", "role" and
+// "disposable", or "ok" if there are none
+func (c EmailCheck) Flags() string {
+	if c.Err != nil {
+		return "invalid: " + c.Err.Error()
+	}
+
+	var flags []string
+	if c.Typo != "" {
+		flags = append(flags, "typo:"+c.Typo)
+	}
+	if c.Role {
+		flags = append(flags, "role")
+	}
+	if c.Disposable {
+		flags = append(flags, "disposable")
+	}
+	if len(flags) == 0 {
+		return "ok"
+	}
+	return strings.Join(flags, ",")
+}
+
+// CheckEmail validates an address and lower cases its domain, corrects a typo in the domain if
+// opts.FixTypos is set, canonicalizes it if opts.Canonicalize is set and flags role and disposable
+// addresses
+func CheckEmail(email string, opts EmailOptions) EmailCheck {
+	email = strings.TrimSpace(email)
+	c := EmailCheck{Email: email}
+	if c.Err = ValidateEmail(email); c.Err != nil {
+		return c
+	}
+
+	at := strings.LastIndexByte(email, '@')
+	local, domain := email[:at], strings.ToLower(email[at+1:])
+
+	if fixed, ok := emailTypos[domain]; ok {
+		c.Typo = fixed
+		if opts.FixTypos {
+			domain = fixed
+		}
+	}
+
+	if _, ok := roleAccounts[strings.ToLower(local)]; ok {
+		c.Role = true
+	}
+
+	for d := domain; d != ""; {
+		if _, ok := opts.Disposable[d]; ok {
+			c.Disposable = true
+			break
+		}
+		i := strings.IndexByte(d, '.')
+		if i < 0 {
+			break
+		}
+		d = d[i+1:]
+	}
+
+	if p, ok := opts.Providers[domain]; ok && opts.Canonicalize {
+		if p.StripPlus {
+			if i := strings.IndexByte(local, '+'); i > 0 {
+				local = local[:i]
+			}
+		}
+		if p.StripDots {
+			local = strings.ReplaceAll(local, ".", "")
+		}
+		local = strings.ToLower(local)
+		if p.Domain != "" {
+			domain = p.Domain
+		}
+	}
+
+	c.Email = local + "@" + domain
+	return c
+}
+
+// CleanEmails checks every line as an address and returns the cleaned addresses and the rejected
+// lines, each followed by a tab and the reason it was rejected
+func CleanEmails(lines []string, opts EmailOptions) ([]string, []string) {
+	var kept, rejected []string
+	for _, line := range lines {
+		c := CheckEmail(line, opts)
+		switch {
+		case c.Err != nil:
+			rejected = append(rejected, line+"\tinvalid: "+c.Err.Error())
+		case c.Role && opts.RejectRole:
+			rejected = append(rejected, line+"\trole account")
+		case c.Disposable && opts.RejectDisposable:
+			rejected = append(rejected, line+"\tdisposable domain")
+		default:
+			kept = append(kept, c.Email)
+		}
+	}
+	return kept, rejected
+}
+
+// CleanEmailsFile cleans the addresses of src into dst, writing rejected lines with their reasons
+// to rejects unless it is empty. It returns the number of kept and rejected lines
+func CleanEmailsFile(src, dst, rejects string, opts EmailOptions) (int, int, error) {
+	lines, style, err := ReadFileStyle(src)
+	if err != nil {
+		return 0, 0, fmt.Errorf("clean emails file: %w", err)
+	}
+
+	kept, rejected := CleanEmails(lines, opts)
+
+	err = WriteFileStyle(dst, kept, style)
+	if err != nil {
+		return 0, 0, fmt.Errorf("clean emails file: %w", err)
+	}
+
+	if rejects != "" {
+		err = WriteFileStyle(rejects, rejected, style)
+		if err != nil {
+			return 0, 0, fmt.Errorf("clean emails file: %w", err)
+		}
+	}
+
+	return len(kept), len(rejected), nil
+}
+
+// CheckEmailsFile writes every line of src to dst followed by a tab and its check flags
+func CheckEmailsFile(src, dst string, opts EmailOptions) (int, error) {
+	lines, style, err := ReadFileStyle(src)
+	if err != nil {
+		return 0, fmt.Errorf("check emails file: %w", err)
+	}
+
+	ok := 0
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		flags := CheckEmail(line, opts).Flags()
+		if flags == "ok" {
+			ok++
+		}
+		result[i] = line + "\t" + flags
+	}
+
+	err = WriteFileStyle(dst, result, style)
+	if err != nil {
+		return 0, fmt.Errorf("check emails file: %w", err)
+	}
+
+	return ok, nil
+}
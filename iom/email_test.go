@@ -0,0 +1,79 @@
+package iom
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_ValidateEmail(t *testing.T) {
+	valid := []string{"a@b.co", "first.last+tag@sub.example.com", "o'neil@example.org", "x@xn--80ak6aa92e.com"}
+	for _, e := range valid {
+		if err := ValidateEmail(e); err != nil {
+			t.Errorf("ValidateEmail(%q) = %v, want nil", e, err)
+		}
+	}
+
+	invalid := []string{"", "plain", "@example.com", "a@", "a..b@example.com", ".a@example.com",
+		"a@example", "a@-example.com", "a@example.c", "a b@example.com", "a@exa_mple.com", "a@example.123"}
+	for _, e := range invalid {
+		if err := ValidateEmail(e); err == nil {
+			t.Errorf("ValidateEmail(%q) = nil, want error", e)
+		}
+	}
+}
+
+func Test_CheckEmail(t *testing.T) {
+	opts := EmailOptions{
+		FixTypos:     true,
+		Canonicalize: true,
+		Providers:    DefaultEmailProviders(),
+		Disposable:   DisposableDomains(),
+	}
+
+	tests := []struct {
+		in    string
+		want  string
+		flags string
+	}{
+		{"John.Doe+news@GMail.com", "johndoe@gmail.com", "ok"},
+		{"j.doe@googlemail.com", "jdoe@gmail.com", "ok"},
+		{"ann@gmial.com", "ann@gmail.com", "typo:gmail.com"},
+		{"Info@Example.COM", "Info@example.com", "role"},
+		{"x@sub.mailinator.com", "x@sub.mailinator.com", "disposable"},
+		{"a.b+c@example.com", "a.b+c@example.com", "ok"},
+	}
+
+	for _, tt := range tests {
+		c := CheckEmail(tt.in, opts)
+		if c.Email != tt.want || c.Flags() != tt.flags {
+			t.Errorf("CheckEmail(%q) = %q %q, want %q %q", tt.in, c.Email, c.Flags(), tt.want, tt.flags)
+		}
+	}
+}
+
+func Test_CleanEmails(t *testing.T) {
+	opts := EmailOptions{RejectRole: true, RejectDisposable: true, Disposable: DisposableDomains()}
+
+	kept, rejected := CleanEmails([]string{"a@x.com", "bad", "admin@x.com", "b@yopmail.com"}, opts)
+	if !reflect.DeepEqual(kept, []string{"a@x.com"}) {
+		t.Errorf("CleanEmails() kept = %v", kept)
+	}
+	want := []string{"bad\tinvalid: missing @", "admin@x.com\trole account", "b@yopmail.com\tdisposable domain"}
+	if !reflect.DeepEqual(rejected, want) {
+		t.Errorf("CleanEmails() rejected = %q, want %q", rejected, want)
+	}
+}
+
+func Test_ParseEmailProvider(t *testing.T) {
+	domain, p, err := ParseEmailProvider("Alias.com=plus,domain:example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if domain != "alias.com" || p != (EmailProvider{StripPlus: true, Domain: "example.com"}) {
+		t.Errorf("ParseEmailProvider() = %q %+v", domain, p)
+	}
+
+	if _, _, err := ParseEmailProvider("example.com=nope"); err == nil {
+		t.Error("ParseEmailProvider() expected error for unknown rule")
+	}
+}
@@ -0,0 +1,220 @@
+package iom
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Encoding names with special handling. Any other name known to the WHATWG encoding standard,
+// e.g. windows-1252 or shift_jis, is also accepted
+const (
+	EncodingAuto    = "auto"
+	EncodingUTF8    = "utf-8"
+	EncodingUTF16LE = "utf-16le"
+	EncodingUTF16BE = "utf-16be"
+	// EncodingFallback is detected for input that is not valid UTF-8 or UTF-16
+	EncodingFallback = "windows-1252"
+)
+
+// sniffSize is how much of a file DetectEncoding looks at
+const sniffSize = 64 * 1024
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// lookupEncoding returns the canonical name and encoding of an encoding name. UTF-8 has no
+// encoding, as it needs no conversion
+func lookupEncoding(name string) (string, encoding.Encoding, error) {
+	switch strings.ToLower(name) {
+	case EncodingUTF8, "utf8":
+		return EncodingUTF8, nil, nil
+	case EncodingUTF16LE, "utf-16":
+		return EncodingUTF16LE, unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), nil
+	case EncodingUTF16BE:
+		return EncodingUTF16BE, unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), nil
+	}
+
+	e, err := htmlindex.Get(name)
+	if err != nil {
+		return "", nil, fmt.Errorf("unknown encoding %q", name)
+	}
+	canonical, _ := htmlindex.Name(e)
+	return canonical, e, nil
+}
+
+// ValidateEncoding checks an encoding name is known
+func ValidateEncoding(name string) error {
+	if name == EncodingAuto {
+		return nil
+	}
+	_, _, err := lookupEncoding(name)
+	return err
+}
+
+// DetectEncoding guesses the encoding of the start of a file. A byte order mark decides, then
+// text with many NUL bytes at odd or even offsets is UTF-16, valid UTF-8 is UTF-8 and anything
+// else is EncodingFallback. It returns the encoding name and the length of the byte order mark
+func DetectEncoding(b []byte) (string, int) {
+	switch {
+	case bytes.HasPrefix(b, bomUTF8):
+		return EncodingUTF8, len(bomUTF8)
+	case bytes.HasPrefix(b, bomUTF16LE):
+		return EncodingUTF16LE, len(bomUTF16LE)
+	case bytes.HasPrefix(b, bomUTF16BE):
+		return EncodingUTF16BE, len(bomUTF16BE)
+	}
+
+	var even, odd int
+	for i, c := range b {
+		if c == 0 {
+			if i%2 == 0 {
+				even++
+			} else {
+				odd++
+			}
+		}
+	}
+	if half := len(b) / 2; half > 0 {
+		switch {
+		case odd > half/2 && even*10 < odd:
+			return EncodingUTF16LE, 0
+		case even > half/2 && odd*10 < even:
+			return EncodingUTF16BE, 0
+		}
+	}
+
+	// the sample may end within a rune
+	if len(b) == sniffSize {
+		for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+			if utf8.RuneStart(b[i]) {
+				if !utf8.FullRune(b[i:]) {
+					b = b[:i]
+				}
+				break
+			}
+		}
+	}
+	if utf8.Valid(b) {
+		return EncodingUTF8, 0
+	}
+	return EncodingFallback, 0
+}
+
+// DetectFileEncoding guesses the encoding of a file, decrypting it first if it is encrypted
+func DetectFileEncoding(file string) (string, error) {
+	r, f, err := openRaw(file)
+	if err != nil {
+		return "", fmt.Errorf("detect file encoding: %w", err)
+	}
+	defer f.Close()
+
+	b, err := r.Peek(sniffSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return "", fmt.Errorf("detect file encoding: %w", err)
+	}
+	name, _ := detectInputEncoding(b, readSep)
+	return name, nil
+}
+
+// detectInputEncoding is DetectEncoding for input whose records, separated by sep, may be
+// separated by NUL bytes, which would look like UTF-16
+func detectInputEncoding(b []byte, sep *recordSep) (string, int) {
+	name, bom := DetectEncoding(b)
+	if bom == 0 && sep.matchesNUL() {
+		name, _ = DetectEncoding(bytes.ReplaceAll(b, []byte{0}, []byte{'\n'}))
+	}
+	return name, bom
+}
+
+// decodeInput converts r, of records separated by sep, from an encoding to UTF-8. A byte order
+// mark is dropped, and overrides the encoding if it is auto
+func decodeInput(r *bufio.Reader, name string, sep *recordSep) (io.Reader, error) {
+	b, err := r.Peek(sniffSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+
+	detected, bom := detectInputEncoding(b, sep)
+	if name == "" || name == EncodingAuto {
+		name = detected
+	} else if bom > 0 {
+		// only drop a byte order mark of the requested encoding
+		if canonical, _, _ := lookupEncoding(name); canonical != detected {
+			bom = 0
+		}
+	}
+	if _, err := r.Discard(bom); err != nil {
+		return nil, err
+	}
+
+	_, e, err := lookupEncoding(name)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return r, nil
+	}
+	return transform.NewReader(r, e.NewDecoder()), nil
+}
+
+// lineEncoder encodes the lines written by a LineWriter
+type lineEncoder struct {
+	enc *encoding.Encoder
+	bom []byte
+}
+
+// newLineEncoder returns the encoder of an output encoding, or nil for UTF-8. UTF-16 output starts
+// with a byte order mark
+func newLineEncoder(name string) (*lineEncoder, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	canonical, e, err := lookupEncoding(name)
+	if err != nil || e == nil {
+		return nil, err
+	}
+
+	le := &lineEncoder{enc: e.NewEncoder()}
+	switch canonical {
+	case EncodingUTF16LE:
+		le.bom = bomUTF16LE
+	case EncodingUTF16BE:
+		le.bom = bomUTF16BE
+	}
+	return le, nil
+}
+
+// InvalidUTF8Lines returns the positions of the lines of a file that are not valid UTF-8. The
+// file is read as stored, without encoding conversion
+func InvalidUTF8Lines(file string) ([]Position, error) {
+	r, f, err := openRaw(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid utf-8 lines: %w", err)
+	}
+	defer f.Close()
+
+	var result []Position
+	lr := newLineReader(file, f, r)
+	for line, ok := lr.next(); ok; line, ok = lr.next() {
+		if !utf8.Valid(line) {
+			result = append(result, Position{File: file, Line: lr.Line()})
+		}
+	}
+	if err := lr.Err(); err != nil {
+		return nil, fmt.Errorf("invalid utf-8 lines: %w", err)
+	}
+	return result, nil
+}
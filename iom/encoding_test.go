@@ -0,0 +1,108 @@
+package iom
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_DetectEncoding(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		want string
+		bom  int
+	}{
+		{"utf-8 bom", []byte("\xEF\xBB\xBFabc"), EncodingUTF8, 3},
+		{"utf-16le bom", []byte("\xFF\xFEa\x00"), EncodingUTF16LE, 2},
+		{"utf-16be bom", []byte("\xFE\xFF\x00a"), EncodingUTF16BE, 2},
+		{"utf-16le", []byte("a\x00b\x00\n\x00"), EncodingUTF16LE, 0},
+		{"utf-16be", []byte("\x00a\x00b\x00\n"), EncodingUTF16BE, 0},
+		{"utf-8", []byte("café\n"), EncodingUTF8, 0},
+		{"windows-1252", []byte("caf\xE9\n"), EncodingFallback, 0},
+		{"empty", nil, EncodingUTF8, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, bom := DetectEncoding(tt.in)
+			if got != tt.want || bom != tt.bom {
+				t.Errorf("DetectEncoding() = %q, %d, want %q, %d", got, bom, tt.want, tt.bom)
+			}
+		})
+	}
+}
+
+func Test_ReadFileEncodings(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"utf16.txt":  []byte("\xFF\xFEc\x00a\x00f\x00\xE9\x00\n\x00x\x00\n\x00"),
+		"cp1252.txt": []byte("caf\xE9\nx\n"),
+		"bom.txt":    []byte("\xEF\xBB\xBFcafé\nx\n"),
+	}
+
+	for name, b := range files {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, b, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"café", "x"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("ReadFile(%s) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func Test_WriteFileEncoding(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "out.txt")
+	setTestFileOptions(t, FileOptions{OutputEncoding: "utf-16le"})
+
+	if err := WriteFile(file, []string{"café"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := AppendFile(file, []string{"x"}); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _ := os.ReadFile(file)
+	want := []byte("\xFF\xFEc\x00a\x00f\x00\xE9\x00\n\x00x\x00\n\x00")
+	if !bytes.Equal(raw, want) {
+		t.Errorf("WriteFile() wrote %q, want %q", raw, want)
+	}
+
+	got, err := ReadFile(file)
+	if err != nil || !reflect.DeepEqual(got, []string{"café", "x"}) {
+		t.Errorf("ReadFile() = %q, %v", got, err)
+	}
+
+	SetFileOptions(FileOptions{OutputEncoding: "windows-1252"})
+	if err := WriteFile(file, []string{"ok", "日本"}); err == nil {
+		t.Error("WriteFile() expected error for a character windows-1252 cannot encode")
+	}
+
+	if err := SetFileOptions(FileOptions{InputEncoding: "klingon"}); err == nil {
+		t.Error("SetFileOptions() expected error for an unknown encoding")
+	}
+}
+
+func Test_InvalidUTF8Lines(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "mixed.txt")
+	if err := os.WriteFile(file, []byte("ok\ncaf\xE9\ncafé\n\xFF\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := InvalidUTF8Lines(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Position{{File: file, Line: 2}, {File: file, Line: 4}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("InvalidUTF8Lines() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,155 @@
+package iom
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// Line endings accepted by FileOptions.EOL
+const (
+	EOLLF       = "lf"
+	EOLCRLF     = "crlf"
+	EOLPreserve = "preserve"
+)
+
+// Final newline modes accepted by FileOptions.FinalNewline
+const (
+	FinalNewlineAlways   = "always"
+	FinalNewlineNever    = "never"
+	FinalNewlinePreserve = "preserve"
+)
+
+// LineStyle is how the lines of a file end. A LineReader detects the style of its file, and a
+// LineWriter given it preserves it unless FileOptions override it
+type LineStyle struct {
+	eol          string
+	finalNewline bool
+}
+
+func validateLineStyle(eol, finalNewline string) error {
+	switch eol {
+	case "", EOLLF, EOLCRLF, EOLPreserve:
+	default:
+		return fmt.Errorf("invalid line ending %q, expected lf, crlf or preserve", eol)
+	}
+	switch finalNewline {
+	case "", FinalNewlineAlways, FinalNewlineNever, FinalNewlinePreserve:
+	default:
+		return fmt.Errorf("invalid final newline %q, expected always, never or preserve", finalNewline)
+	}
+	return nil
+}
+
+// outputStyle returns the line style of files written from an input of style in. Preserved
+// settings follow in, or are lf and a final newline if in is nil
+func outputStyle(in *LineStyle) LineStyle {
+	s := LineStyle{eol: EOLLF, finalNewline: true}
+	if in != nil {
+		s = *in
+	}
+
+	switch fileOptions.EOL {
+	case EOLLF, EOLCRLF:
+		s.eol = fileOptions.EOL
+	}
+	switch fileOptions.FinalNewline {
+	case FinalNewlineAlways:
+		s.finalNewline = true
+	case FinalNewlineNever:
+		s.finalNewline = false
+	}
+	return s
+}
+
+func (s LineStyle) newline() string {
+	if s.eol == EOLCRLF {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// lineSplitter drops the newline ending a line, and a \r before it, and counts how lines end
+type lineSplitter struct {
+	lf, crlf   int
+	terminated bool
+}
+
+// trim returns a line without its ending. Terminated lines end with \n, and the last line of a
+// file may not
+func (s *lineSplitter) trim(line []byte, terminated bool) []byte {
+	s.terminated = terminated
+	if terminated {
+		line = line[:len(line)-1]
+	}
+
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		line = line[:n-1]
+		if terminated {
+			s.crlf++
+		}
+	} else if terminated {
+		s.lf++
+	}
+	return line
+}
+
+// style returns the detected line style, or false if no line was read
+func (s *lineSplitter) style() (LineStyle, bool) {
+	if s.lf == 0 && s.crlf == 0 && !s.terminated {
+		return LineStyle{}, false
+	}
+
+	style := LineStyle{eol: EOLLF, finalNewline: s.terminated}
+	if s.crlf > s.lf {
+		style.eol = EOLCRLF
+	}
+	return style, true
+}
+
+// peekStyle detects the line style of the buffered start of a reader. Unless the whole input is
+// buffered, its last line is assumed to end with a newline
+func peekStyle(r *bufio.Reader) (LineStyle, bool) {
+	b, err := r.Peek(r.Size())
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return LineStyle{}, false
+	}
+
+	s := lineSplitter{terminated: err != io.EOF || (len(b) > 0 && b[len(b)-1] == '\n')}
+	for i, c := range b {
+		if c != '\n' {
+			continue
+		}
+		if i > 0 && b[i-1] == '\r' {
+			s.crlf++
+		} else {
+			s.lf++
+		}
+	}
+	if len(b) == 0 {
+		return LineStyle{}, false
+	}
+	return s.style()
+}
+
+// DetectLineEndings reads a file and returns its line ending, lf or crlf, and whether its last
+// line ends with a newline
+func DetectLineEndings(file string) (string, bool, error) {
+	r, err := OpenLineReader(file)
+	if err != nil {
+		return "", false, fmt.Errorf("detect line endings: %w", err)
+	}
+	defer r.Close()
+
+	for _, ok := r.Next(); ok; _, ok = r.Next() {
+	}
+	if err := r.Err(); err != nil {
+		return "", false, fmt.Errorf("detect line endings: %w", err)
+	}
+
+	style, ok := r.splitter.style()
+	if !ok {
+		return EOLLF, false, nil
+	}
+	return style.eol, style.finalNewline, nil
+}
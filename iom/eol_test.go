@@ -0,0 +1,108 @@
+package iom
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_ReadFileLineEndings(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "list.txt")
+	if err := os.WriteFile(file, []byte("a\r\nb\nc\r\nc\r"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b", "c", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadFile() = %q, want %q", got, want)
+	}
+
+	eol, final, err := DetectLineEndings(file)
+	if err != nil || eol != EOLCRLF || final {
+		t.Errorf("DetectLineEndings() = %q, %v, %v, want crlf, false", eol, final, err)
+	}
+}
+
+func Test_WriteFileLineEndings(t *testing.T) {
+	dir := t.TempDir()
+	crlf := filepath.Join(dir, "crlf.txt")
+	if err := os.WriteFile(crlf, []byte("a\r\nb"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		opts  FileOptions
+		read  bool
+		want  string
+		final string
+	}{
+		{"default without input", FileOptions{}, false, "x\ny\n", "x\ny\nz\n"},
+		{"preserve input", FileOptions{}, true, "x\r\ny", "x\r\ny\r\nz"},
+		{"crlf", FileOptions{EOL: EOLCRLF, FinalNewline: FinalNewlineAlways}, false, "x\r\ny\r\n", "x\r\ny\r\nz\r\n"},
+		{"lf over input", FileOptions{EOL: EOLLF}, true, "x\ny", "x\ny\nz"},
+		{"never", FileOptions{FinalNewline: FinalNewlineNever}, false, "x\ny", "x\ny\nz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestFileOptions(t, tt.opts)
+			var style *LineStyle
+			if tt.read {
+				var err error
+				if _, style, err = ReadFileStyle(crlf); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			file := filepath.Join(dir, "out.txt")
+			if err := WriteFileStyle(file, []string{"x", "y"}, style); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := os.ReadFile(file); string(got) != tt.want {
+				t.Errorf("WriteFileStyle() wrote %q, want %q", got, tt.want)
+			}
+
+			if err := AppendFileStyle(file, []string{"z"}, style); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := os.ReadFile(file); string(got) != tt.final {
+				t.Errorf("AppendFileStyle() wrote %q, want %q", got, tt.final)
+			}
+		})
+	}
+
+	// A file read later must not change the style of files written from an earlier one
+	lf := filepath.Join(dir, "lf.txt")
+	if err := os.WriteFile(lf, []byte("a\nb\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	crlfOut, lfOut := filepath.Join(dir, "crlf-out.txt"), filepath.Join(dir, "lf-out.txt")
+	lines, crlfStyle, err := ReadFileStyle(crlf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ReadFileStyle(lf); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileStyle(crlfOut, lines, crlfStyle); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RemoveDuplicatesFile(lf, lfOut); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(crlfOut); string(got) != "a\r\nb" {
+		t.Errorf("WriteFileStyle() wrote %q, want %q", got, "a\r\nb")
+	}
+	if got, _ := os.ReadFile(lfOut); string(got) != "a\nb\n" {
+		t.Errorf("RemoveDuplicatesFile() wrote %q, want %q", got, "a\nb\n")
+	}
+
+	if err := SetFileOptions(FileOptions{EOL: "cr"}); err == nil {
+		t.Error("SetFileOptions() expected error for an unknown line ending")
+	}
+}
@@ -0,0 +1,243 @@
+package iom
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+)
+
+// Extractor finds entities of one kind in free text
+type Extractor struct {
+	Name string
+
+	re    *regexp.Regexp
+	clean func(string) (string, bool)
+}
+
+// builtin extractor regexes, compiled once
+var (
+	emailRe  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@(?:[A-Za-z0-9](?:[A-Za-z0-9\-]*[A-Za-z0-9])?\.)+[A-Za-z]{2,63}`)
+	urlRe    = regexp.MustCompile(`(?i)\b(?:https?|ftp)://[^\s<>"'` + "`" + `]+`)
+	domainRe = regexp.MustCompile(`(?i)\b(?:[a-z0-9](?:[a-z0-9\-]{0,61}[a-z0-9])?\.)+[a-z][a-z0-9\-]{0,61}[a-z0-9]\b`)
+	octet    = `(?:25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])`
+	ipv4Re   = regexp.MustCompile(`\b` + octet + `(?:\.` + octet + `){3}\b`)
+	// RE2 has no lookbehind, so the character before an address is matched outside its group
+	ipv6Re  = regexp.MustCompile(`(?i)(?:^|[^0-9a-z:.])([0-9a-f]{0,4}(?::[0-9a-f]{0,4}){2,7}(?:(?:\.[0-9]{1,3}){3}|%[0-9a-z]+)?)`)
+	dateRe  = regexp.MustCompile(`^(?:[0-9]{4}[./\-][0-9]{1,2}[./\-][0-9]{1,2}|[0-9]{1,2}[./\-][0-9]{1,2}[./\-][0-9]{4})\b`)
+	phoneRe = regexp.MustCompile(`(?:\+|\b00)?\(?[0-9]{1,4}\)?(?:[ .\-]?\(?[0-9]{1,4}\)?){2,5}\b`)
+)
+
+var builtinExtractors = map[string]Extractor{
+	"email": {
+		Name: "email",
+		re:   emailRe,
+		clean: func(s string) (string, bool) {
+			return s, !strings.HasPrefix(s, ".") && !strings.Contains(s, "..")
+		},
+	},
+	"url": {
+		Name: "url",
+		re:   urlRe,
+		clean: func(s string) (string, bool) {
+			s = strings.TrimRight(s, ".,;:!?")
+			// drop an unbalanced closing bracket, as in "(see http://x.com/a)"
+			for _, pair := range []string{"()", "[]", "{}"} {
+				if strings.HasSuffix(s, pair[1:]) && strings.Count(s, pair[:1]) < strings.Count(s, pair[1:]) {
+					s = s[:len(s)-1]
+				}
+			}
+			return s, true
+		},
+	},
+	"domain": {
+		Name:  "domain",
+		re:    domainRe,
+		clean: func(s string) (string, bool) { return strings.ToLower(s), true },
+	},
+	"ipv4": {
+		Name:  "ipv4",
+		re:    ipv4Re,
+		clean: func(s string) (string, bool) { return s, true },
+	},
+	"ipv6": {
+		Name: "ipv6",
+		re:   ipv6Re,
+		clean: func(s string) (string, bool) {
+			// candidates without a decimal digit, such as :: or a::b, are text like separators
+			// and C++ scopes far more often than addresses; :: itself must be written as ::0
+			if !strings.ContainsAny(s, "0123456789") {
+				return s, false
+			}
+			addr, err := netip.ParseAddr(s)
+			return s, err == nil && addr.Is6()
+		},
+	},
+	"phone": {
+		Name: "phone",
+		re:   phoneRe,
+		clean: func(s string) (string, bool) {
+			s = strings.TrimSpace(s)
+			if dateRe.MatchString(s) {
+				return s, false
+			}
+			digits := 0
+			for _, r := range s {
+				if r >= '0' && r <= '9' {
+					digits++
+				}
+			}
+			// numbers without a country code or area code in brackets need enough digits to not
+			// be mistaken for other numbers
+			min := 10
+			if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "(") || strings.HasPrefix(s, "00") {
+				min = 7
+			}
+			return s, digits >= min && digits <= 15
+		},
+	},
+}
+
+// BuiltinExtractors returns the names of the built in extractors
+func BuiltinExtractors() []string {
+	return []string{"email", "url", "domain", "ipv4", "ipv6", "phone"}
+}
+
+// BuiltinExtractor returns the built in extractor with the given name
+func BuiltinExtractor(name string) (Extractor, error) {
+	e, ok := builtinExtractors[name]
+	if !ok {
+		return Extractor{}, fmt.Errorf("unknown extractor %q, expected one of %s", name, strings.Join(BuiltinExtractors(), ", "))
+	}
+	return e, nil
+}
+
+// RegexExtractor returns an extractor for a custom regex. If the regex has a capturing group, the
+// first group is extracted instead of the whole match
+func RegexExtractor(expr string) (Extractor, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return Extractor{}, fmt.Errorf("regex extractor: %w", err)
+	}
+	return Extractor{Name: expr, re: re}, nil
+}
+
+// FindAll returns every entity in a line, in order
+func (e Extractor) FindAll(line string) []string {
+	var result []string
+	for _, m := range e.matches(line) {
+		if m.value != "" {
+			result = append(result, m.value)
+		}
+	}
+	return result
+}
+
+// ReplaceAll replaces every entity in a line with the result of fn
+func (e Extractor) ReplaceAll(line string, fn func(string) string) string {
+	matches := e.matches(line)
+	if len(matches) == 0 {
+		return line
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, m := range matches {
+		sb.WriteString(line[last:m.start])
+		sb.WriteString(fn(line[m.start:m.end]))
+		last = m.end
+	}
+	sb.WriteString(line[last:])
+	return sb.String()
+}
+
+type extractorMatch struct {
+	start, end int
+	value      string
+}
+
+// matches returns every entity in a line: the first capturing group of each match if the regex
+// has one, or else the whole match. Matches rejected by clean are skipped
+func (e Extractor) matches(line string) []extractorMatch {
+	var result []extractorMatch
+	for _, loc := range e.re.FindAllStringSubmatchIndex(line, -1) {
+		m := extractorMatch{start: loc[0], end: loc[1]}
+		if len(loc) > 3 {
+			if loc[2] < 0 {
+				continue
+			}
+			m.start, m.end = loc[2], loc[3]
+		}
+		m.value = line[m.start:m.end]
+		if e.clean != nil {
+			var ok bool
+			if m.value, ok = e.clean(m.value); !ok {
+				continue
+			}
+		}
+		result = append(result, m)
+	}
+	return result
+}
+
+// Match is an extracted entity and where it was found
+type Match struct {
+	Value string
+	Pos   Position
+}
+
+// Extract returns every entity found by the extractors in the lines of a file. With dedupe, only
+// the first occurrence of each value is kept, using seen to remember values across calls
+func Extract(file string, lines []string, exs []Extractor, seen map[string]struct{}) []Match {
+	var result []Match
+	for n, line := range lines {
+		for _, e := range exs {
+			for _, v := range e.FindAll(line) {
+				if seen != nil {
+					if _, ok := seen[v]; ok {
+						continue
+					}
+					seen[v] = struct{}{}
+				}
+				result = append(result, Match{Value: v, Pos: Position{File: file, Line: n + 1}})
+			}
+		}
+	}
+	return result
+}
+
+// ExtractFiles extracts entities from files and writes one per line to out, each prefixed with
+// its file:line and a tab when annotate is set. It returns the number of entities written
+func ExtractFiles(files []string, out string, exs []Extractor, dedupe, annotate bool) (int, error) {
+	var seen map[string]struct{}
+	if dedupe {
+		seen = make(map[string]struct{})
+	}
+
+	var result []string
+	var style *LineStyle
+	for _, file := range files {
+		lines, s, err := ReadFileStyle(file)
+		if err != nil {
+			return 0, fmt.Errorf("extract files: %w", err)
+		}
+		if style == nil {
+			style = s
+		}
+
+		for _, m := range Extract(file, lines, exs, seen) {
+			if annotate {
+				result = append(result, m.Pos.String()+"\t"+m.Value)
+			} else {
+				result = append(result, m.Value)
+			}
+		}
+	}
+
+	err := WriteFileStyle(out, result, style)
+	if err != nil {
+		return 0, fmt.Errorf("extract files: %w", err)
+	}
+
+	return len(result), nil
+}
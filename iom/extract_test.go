@@ -0,0 +1,62 @@
+package iom
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_BuiltinExtractor(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"email", `<a href="mailto:ann.b+x@mail.example.com">Ann</a>, bob@x.io.`, []string{"ann.b+x@mail.example.com", "bob@x.io"}},
+		{"url", "see (https://example.com/a?b=1). or http://x.io/path,", []string{"https://example.com/a?b=1", "http://x.io/path"}},
+		{"domain", "Visit WWW.Example.com or api.x.io today", []string{"www.example.com", "api.x.io"}},
+		{"ipv4", "from 10.0.0.1 to 256.1.1.1 and 192.168.1.254", []string{"10.0.0.1", "192.168.1.254"}},
+		{"ipv6", "addr 2001:db8::1 and fe80::1%eth0, not 12:30", []string{"2001:db8::1", "fe80::1%eth0"}},
+		{"ipv6 not within a word", "deadbeef::1 and x::1, ::1", []string{"::1"}},
+		{"ipv6 not separators or scopes", "Home :: About, a::b, std::vector and ::0", []string{"::0"}},
+		{"phone", "call +1 (555) 123-4567 or 555-123-4567 ext 12", []string{"+1 (555) 123-4567", "555-123-4567"}},
+		{"phone not a date or short number", "on 2024-01-15 10:30, 15.01.2024 or 555-1234", nil},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			e, err := BuiltinExtractor(strings.Fields(tt.name)[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := e.FindAll(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindAll(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_RegexExtractor(t *testing.T) {
+	e, err := RegexExtractor(`id=(\d+)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := e.FindAll("id=1&id=22"); !reflect.DeepEqual(got, []string{"1", "22"}) {
+		t.Errorf("FindAll() = %q", got)
+	}
+}
+
+func Test_Extract_Dedupe(t *testing.T) {
+	e, _ := BuiltinExtractor("email")
+	got := Extract("a.txt", []string{"a@x.com b@x.com", "a@x.com"}, []Extractor{e}, map[string]struct{}{})
+	want := []Match{
+		{Value: "a@x.com", Pos: Position{File: "a.txt", Line: 1}},
+		{Value: "b@x.com", Pos: Position{File: "a.txt", Line: 1}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Extract() = %v, want %v", got, want)
+	}
+}
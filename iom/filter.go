@@ -0,0 +1,231 @@
+package iom
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Character classes accepted by Filter.Charsets
+var charsets = map[string]func(rune) bool{
+	"ascii":     func(r rune) bool { return r < utf8.RuneSelf },
+	"digits":    func(r rune) bool { return r >= '0' && r <= '9' },
+	"alpha":     unicode.IsLetter,
+	"alnum":     func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) },
+	"printable": unicode.IsPrint,
+	"space":     unicode.IsSpace,
+}
+
+// FieldPredicate tests one field of a delimited line
+type FieldPredicate struct {
+	Field int
+	Op    string
+	Value string
+
+	re  *regexp.Regexp
+	num float64
+}
+
+var fieldPredicateOps = []string{"!~", "!=", ">=", "<=", "~", "=", ">", "<"}
+
+// ParseFieldPredicate parses a predicate such as "2~^foo", "3>100" or "0=bar". Operators are =, !=,
+// ~ and !~ (regex match), and the numeric comparisons >, >=, < and <=
+func ParseFieldPredicate(s string) (FieldPredicate, error) {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	if i == 0 {
+		return FieldPredicate{}, fmt.Errorf("parse field predicate: %q must start with a field id", s)
+	}
+
+	p := FieldPredicate{}
+	p.Field, _ = strconv.Atoi(s[:i])
+	for _, op := range fieldPredicateOps {
+		if strings.HasPrefix(s[i:], op) {
+			p.Op = op
+			p.Value = s[i+len(op):]
+			break
+		}
+	}
+
+	var err error
+	switch p.Op {
+	case "":
+		return FieldPredicate{}, fmt.Errorf("parse field predicate: %q has no operator", s)
+	case "~", "!~":
+		p.re, err = regexp.Compile(p.Value)
+	case ">", ">=", "<", "<=":
+		p.num, err = strconv.ParseFloat(p.Value, 64)
+	}
+	if err != nil {
+		return FieldPredicate{}, fmt.Errorf("parse field predicate: %q: %w", s, err)
+	}
+
+	return p, nil
+}
+
+// Match reports whether the field satisfies the predicate. Numeric comparisons never match
+// non-numeric fields
+func (p FieldPredicate) Match(field string) bool {
+	switch p.Op {
+	case "=":
+		return field == p.Value
+	case "!=":
+		return field != p.Value
+	case "~":
+		return p.re.MatchString(field)
+	case "!~":
+		return !p.re.MatchString(field)
+	}
+
+	f, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+	if err != nil {
+		return false
+	}
+	switch p.Op {
+	case ">":
+		return f > p.num
+	case ">=":
+		return f >= p.num
+	case "<":
+		return f < p.num
+	default:
+		return f <= p.num
+	}
+}
+
+// Filter selects lines. A line is kept when it matches any Include pattern (or there are none),
+// no Exclude pattern, is within the length bounds, consists only of runes in Charsets and satisfies
+// every field predicate. Invert keeps exactly the lines that would otherwise be rejected
+type Filter struct {
+	Include []*regexp.Regexp
+	Exclude []*regexp.Regexp
+	// MinLen and MaxLen bound the length of a line in runes, MaxLen 0 means unbounded
+	MinLen int
+	MaxLen int
+	// Charsets are names of the allowed character classes: ascii, digits, alpha, alnum, printable
+	// and space
+	Charsets []string
+	// Fields are tested against the fields of a line split by Delim. Lines missing a field fail
+	Fields []FieldPredicate
+	Delim  string
+	Invert bool
+}
+
+// Validate checks that the filter's character classes exist
+func (f *Filter) Validate() error {
+	for _, c := range f.Charsets {
+		if _, ok := charsets[c]; !ok {
+			return fmt.Errorf("filter: unknown character class %q", c)
+		}
+	}
+	if len(f.Fields) > 0 && f.Delim == "" {
+		return fmt.Errorf("filter: a delimiter is required with field predicates")
+	}
+	return nil
+}
+
+// Match reports whether a line is kept by the filter
+func (f *Filter) Match(line string) bool {
+	return f.match(line) != f.Invert
+}
+
+func (f *Filter) match(line string) bool {
+	if len(f.Include) > 0 {
+		ok := false
+		for _, re := range f.Include {
+			if re.MatchString(line) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	for _, re := range f.Exclude {
+		if re.MatchString(line) {
+			return false
+		}
+	}
+
+	if f.MinLen > 0 || f.MaxLen > 0 {
+		n := utf8.RuneCountInString(line)
+		if n < f.MinLen || (f.MaxLen > 0 && n > f.MaxLen) {
+			return false
+		}
+	}
+
+	if len(f.Charsets) > 0 {
+		for _, r := range line {
+			ok := false
+			for _, c := range f.Charsets {
+				if charsets[c](r) {
+					ok = true
+					break
+				}
+			}
+			if !ok {
+				return false
+			}
+		}
+	}
+
+	if len(f.Fields) > 0 {
+		fields := strings.Split(line, f.Delim)
+		for _, p := range f.Fields {
+			if p.Field >= len(fields) || !p.Match(fields[p.Field]) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// FilterLines splits lines into those kept and those rejected by the filter
+func FilterLines(lines []string, f *Filter) ([]string, []string) {
+	var kept, rejected []string
+	for _, line := range lines {
+		if f.Match(line) {
+			kept = append(kept, line)
+		} else {
+			rejected = append(rejected, line)
+		}
+	}
+	return kept, rejected
+}
+
+// FilterFile writes the lines of src kept by the filter to dst, and the rejected lines to rejects
+// unless it is empty. It returns the number of kept and rejected lines
+func FilterFile(src, dst, rejects string, f *Filter) (int, int, error) {
+	if err := f.Validate(); err != nil {
+		return 0, 0, fmt.Errorf("filter file: %w", err)
+	}
+
+	lines, style, err := ReadFileStyle(src)
+	if err != nil {
+		return 0, 0, fmt.Errorf("filter file: %w", err)
+	}
+
+	kept, rejected := FilterLines(lines, f)
+
+	err = WriteFileStyle(dst, kept, style)
+	if err != nil {
+		return 0, 0, fmt.Errorf("filter file: %w", err)
+	}
+
+	if rejects != "" {
+		err = WriteFileStyle(rejects, rejected, style)
+		if err != nil {
+			return 0, 0, fmt.Errorf("filter file: %w", err)
+		}
+	}
+
+	return len(kept), len(rejected), nil
+}
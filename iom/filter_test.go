@@ -0,0 +1,75 @@
+package iom
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func Test_ParseFieldPredicate(t *testing.T) {
+	tests := []struct {
+		in    string
+		field string
+		want  bool
+	}{
+		{"2~^foo", "foobar", true},
+		{"2!~^foo", "foobar", false},
+		{"3>100", "101", true},
+		{"3>100", "abc", false},
+		{"3<=100", "100", true},
+		{"0=bar", "bar", true},
+		{"0!=bar", "bar", false},
+	}
+
+	for _, tt := range tests {
+		p, err := ParseFieldPredicate(tt.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := p.Match(tt.field); got != tt.want {
+			t.Errorf("ParseFieldPredicate(%q).Match(%q) = %v, want %v", tt.in, tt.field, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"foo", "2", "2>abc", "1~("} {
+		if _, err := ParseFieldPredicate(in); err == nil {
+			t.Errorf("ParseFieldPredicate(%q) expected error", in)
+		}
+	}
+}
+
+func Test_FilterLines(t *testing.T) {
+	lines := []string{"a,1", "b,200", "c,300", "ü,400", "x"}
+
+	p, err := ParseFieldPredicate("1>100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &Filter{
+		Exclude:  []*regexp.Regexp{regexp.MustCompile("^c")},
+		Charsets: []string{"ascii"},
+		Fields:   []FieldPredicate{p},
+		Delim:    ",",
+	}
+
+	kept, rejected := FilterLines(lines, f)
+	if !reflect.DeepEqual(kept, []string{"b,200"}) {
+		t.Errorf("FilterLines() kept = %v", kept)
+	}
+	if !reflect.DeepEqual(rejected, []string{"a,1", "c,300", "ü,400", "x"}) {
+		t.Errorf("FilterLines() rejected = %v", rejected)
+	}
+
+	f.Invert = true
+	kept, _ = FilterLines(lines, f)
+	if !reflect.DeepEqual(kept, []string{"a,1", "c,300", "ü,400", "x"}) {
+		t.Errorf("FilterLines() inverted kept = %v", kept)
+	}
+}
+
+func Test_FilterLines_Length(t *testing.T) {
+	kept, _ := FilterLines([]string{"a", "abc", "abcdef"}, &Filter{MinLen: 2, MaxLen: 4})
+	if !reflect.DeepEqual(kept, []string{"abc"}) {
+		t.Errorf("FilterLines() = %v", kept)
+	}
+}
@@ -0,0 +1,431 @@
+package iom
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Near-duplicate detection methods
+const (
+	FuzzyMinHash     = "minhash"
+	FuzzyLevenshtein = "levenshtein"
+)
+
+// FuzzyOptions configures near-duplicate detection
+type FuzzyOptions struct {
+	// Method is FuzzyMinHash or FuzzyLevenshtein
+	Method string
+	// Threshold is the minimum similarity, between 0 and 1, for two lines to be near-duplicates
+	Threshold float64
+	// Shingle is the number of characters per shingle used by FuzzyMinHash
+	Shingle int
+	// Hashes is the MinHash signature length used by FuzzyMinHash
+	Hashes int
+	// Window is the number of sorted neighbours each line is compared to by FuzzyLevenshtein
+	Window int
+}
+
+// DefaultFuzzyOptions returns the options used when none are given
+func DefaultFuzzyOptions() FuzzyOptions {
+	return FuzzyOptions{
+		Method:    FuzzyMinHash,
+		Threshold: 0.8,
+		Shingle:   3,
+		Hashes:    128,
+		Window:    10,
+	}
+}
+
+// NormalizeFuzzy lowercases a line and reduces it to letters, digits and single spaces so that
+// case and punctuation differences do not count against similarity
+func NormalizeFuzzy(s string) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && sb.Len() > 0 {
+				sb.WriteByte(' ')
+			}
+			sb.WriteRune(r)
+			space = false
+		case unicode.IsSpace(r):
+			space = true
+		}
+	}
+
+	if sb.Len() == 0 {
+		return s
+	}
+	return sb.String()
+}
+
+// Shingles returns the hashed set of k character shingles of a string
+func Shingles(s string, k int) map[uint64]struct{} {
+	runes := []rune(s)
+	m := make(map[uint64]struct{})
+	if len(runes) <= k {
+		m[hashString(s)] = struct{}{}
+		return m
+	}
+
+	for i := 0; i+k <= len(runes); i++ {
+		m[hashString(string(runes[i:i+k]))] = struct{}{}
+	}
+	return m
+}
+
+// Jaccard returns the Jaccard similarity of two sets
+func Jaccard(a, b map[uint64]struct{}) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+
+	inter := 0
+	for k := range a {
+		if _, ok := b[k]; ok {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(a)+len(b)-inter)
+}
+
+// Levenshtein returns the edit distance between two strings, counted in runes
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(minInt(prev[j]+1, cur[j-1]+1), prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// LevenshteinSimilarity returns 1 minus the edit distance scaled by the longer string's length
+func LevenshteinSimilarity(a, b string) float64 {
+	n := len([]rune(a))
+	if m := len([]rune(b)); m > n {
+		n = m
+	}
+	if n == 0 {
+		return 1
+	}
+	return 1 - float64(Levenshtein(a, b))/float64(n)
+}
+
+// FuzzyClusters groups near-identical lines and returns the line indices of each cluster. Every
+// line belongs to exactly one cluster, indices within a cluster are ascending and clusters are
+// ordered by their first index
+func FuzzyClusters(s []string, opts FuzzyOptions) ([][]int, error) {
+	// lines that are equal once normalized are clustered up front, so that each distinct line is
+	// compared once however often it repeats
+	var norm []string
+	ids := make([]int, len(s))
+	index := make(map[string]int)
+	for i, v := range s {
+		n := NormalizeFuzzy(v)
+		id, ok := index[n]
+		if !ok {
+			id = len(norm)
+			index[n] = id
+			norm = append(norm, n)
+		}
+		ids[i] = id
+	}
+
+	uf := newUnionFind(len(norm))
+	switch opts.Method {
+	case FuzzyMinHash:
+		if opts.Shingle < 1 || opts.Hashes < 1 {
+			return nil, fmt.Errorf("fuzzy clusters: shingle and hashes must be positive")
+		}
+		clusterMinHash(norm, opts, uf)
+	case FuzzyLevenshtein:
+		if opts.Window < 1 {
+			return nil, fmt.Errorf("fuzzy clusters: window must be positive")
+		}
+		clusterLevenshtein(norm, opts, uf)
+	default:
+		return nil, fmt.Errorf("fuzzy clusters: unknown method %q", opts.Method)
+	}
+
+	var result [][]int
+	roots := make(map[int]int)
+	for i := range s {
+		r := uf.find(ids[i])
+		idx, ok := roots[r]
+		if !ok {
+			idx = len(result)
+			roots[r] = idx
+			result = append(result, nil)
+		}
+		result[idx] = append(result[idx], i)
+	}
+
+	return result, nil
+}
+
+// RemoveFuzzyDuplicates keeps the first line of every near-duplicate cluster and returns the
+// number of lines removed
+func RemoveFuzzyDuplicates(s []string, opts FuzzyOptions) (int, []string, error) {
+	clusters, err := FuzzyClusters(s, opts)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	keep := make([]int, len(clusters))
+	for i, c := range clusters {
+		keep[i] = c[0]
+	}
+	sort.Ints(keep)
+
+	result := make([]string, len(keep))
+	for i, k := range keep {
+		result[i] = s[k]
+	}
+
+	return len(s) - len(result), result, nil
+}
+
+// RemoveFuzzyDuplicatesFile removes near-duplicate lines from a file
+func RemoveFuzzyDuplicatesFile(src, dst string, opts FuzzyOptions) (int, error) {
+	lines, style, err := ReadFileStyle(src)
+	if err != nil {
+		return 0, fmt.Errorf("remove fuzzy duplicates file: %w", err)
+	}
+
+	n, lines, err := RemoveFuzzyDuplicates(lines, opts)
+	if err != nil {
+		return 0, fmt.Errorf("remove fuzzy duplicates file: %w", err)
+	}
+
+	err = WriteFileStyle(dst, lines, style)
+	if err != nil {
+		return n, fmt.Errorf("remove fuzzy duplicates file: %w", err)
+	}
+
+	return n, nil
+}
+
+// FuzzyClusterReport returns a tab separated cluster number and line for every line in a cluster
+// with more than one member. Clusters are numbered from 1
+func FuzzyClusterReport(s []string, clusters [][]int) []string {
+	var result []string
+	id := 0
+	for _, c := range clusters {
+		if len(c) < 2 {
+			continue
+		}
+		id++
+		for _, i := range c {
+			result = append(result, strconv.Itoa(id)+"\t"+s[i])
+		}
+	}
+	return result
+}
+
+// FuzzyClusterReportFile writes a cluster report of a file's near-duplicate lines and returns the
+// number of clusters with more than one member
+func FuzzyClusterReportFile(src, dst string, opts FuzzyOptions) (int, error) {
+	lines, style, err := ReadFileStyle(src)
+	if err != nil {
+		return 0, fmt.Errorf("fuzzy cluster report file: %w", err)
+	}
+
+	clusters, err := FuzzyClusters(lines, opts)
+	if err != nil {
+		return 0, fmt.Errorf("fuzzy cluster report file: %w", err)
+	}
+
+	n := 0
+	for _, c := range clusters {
+		if len(c) > 1 {
+			n++
+		}
+	}
+
+	err = WriteFileStyle(dst, FuzzyClusterReport(lines, clusters), style)
+	if err != nil {
+		return n, fmt.Errorf("fuzzy cluster report file: %w", err)
+	}
+
+	return n, nil
+}
+
+// clusterMinHash unions lines that share a MinHash LSH bucket and whose shingle sets reach the
+// Jaccard threshold
+func clusterMinHash(norm []string, opts FuzzyOptions, uf *unionFind) {
+	bands, rows := lshBands(opts.Hashes, opts.Threshold)
+	seeds := make([]uint64, bands*rows)
+	for i := range seeds {
+		seeds[i] = splitmix64(uint64(i) + 1)
+	}
+
+	shingles := make([]map[uint64]struct{}, len(norm))
+	buckets := make(map[uint64][]int)
+	for i, v := range norm {
+		shingles[i] = Shingles(v, opts.Shingle)
+		sig := minHash(shingles[i], seeds)
+		for b := 0; b < bands; b++ {
+			h := fnv.New64a()
+			var buf [8]byte
+			for _, x := range sig[b*rows : (b+1)*rows] {
+				for k := range buf {
+					buf[k] = byte(x >> (8 * k))
+				}
+				h.Write(buf[:])
+			}
+			key := h.Sum64() ^ splitmix64(uint64(b))
+			buckets[key] = append(buckets[key], i)
+		}
+	}
+
+	for _, bucket := range buckets {
+		for x := 1; x < len(bucket); x++ {
+			for y := 0; y < x; y++ {
+				i, j := bucket[y], bucket[x]
+				if uf.find(i) == uf.find(j) {
+					continue
+				}
+				if Jaccard(shingles[i], shingles[j]) >= opts.Threshold {
+					uf.union(i, j)
+				}
+			}
+		}
+	}
+}
+
+// clusterLevenshtein unions lines within a sliding window of each other, sorted forwards and then
+// by their reversed text so that typos near the start of a line are still blocked together
+func clusterLevenshtein(norm []string, opts FuzzyOptions, uf *unionFind) {
+	reversed := make([]string, len(norm))
+	for i, v := range norm {
+		r := []rune(v)
+		for a, b := 0, len(r)-1; a < b; a, b = a+1, b-1 {
+			r[a], r[b] = r[b], r[a]
+		}
+		reversed[i] = string(r)
+	}
+
+	for _, keys := range [][]string{norm, reversed} {
+		order := make([]int, len(keys))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool { return keys[order[a]] < keys[order[b]] })
+
+		for x := range order {
+			for y := x + 1; y < len(order) && y <= x+opts.Window; y++ {
+				i, j := order[x], order[y]
+				if uf.find(i) == uf.find(j) {
+					continue
+				}
+				if LevenshteinSimilarity(norm[i], norm[j]) >= opts.Threshold {
+					uf.union(i, j)
+				}
+			}
+		}
+	}
+}
+
+// lshBands picks the band and row counts whose LSH threshold (1/b)^(1/r) is closest to t
+func lshBands(n int, t float64) (int, int) {
+	bestB, bestR, bestDiff := n, 1, math.MaxFloat64
+	for r := 1; r <= n; r++ {
+		if n%r != 0 {
+			continue
+		}
+		b := n / r
+		diff := math.Abs(math.Pow(1/float64(b), 1/float64(r)) - t)
+		if diff < bestDiff {
+			bestB, bestR, bestDiff = b, r, diff
+		}
+	}
+	return bestB, bestR
+}
+
+func minHash(set map[uint64]struct{}, seeds []uint64) []uint64 {
+	sig := make([]uint64, len(seeds))
+	for i := range sig {
+		sig[i] = math.MaxUint64
+	}
+
+	for x := range set {
+		for i, seed := range seeds {
+			if h := splitmix64(x ^ seed); h < sig[i] {
+				sig[i] = h
+			}
+		}
+	}
+	return sig
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+type unionFind struct {
+	parent []int
+}
+
+func newUnionFind(n int) *unionFind {
+	uf := &unionFind{parent: make([]int, n)}
+	for i := range uf.parent {
+		uf.parent[i] = i
+	}
+	return uf
+}
+
+func (u *unionFind) find(i int) int {
+	for u.parent[i] != i {
+		u.parent[i] = u.parent[u.parent[i]]
+		i = u.parent[i]
+	}
+	return i
+}
+
+func (u *unionFind) union(i, j int) {
+	ri, rj := u.find(i), u.find(j)
+	if ri == rj {
+		return
+	}
+	if ri < rj {
+		u.parent[rj] = ri
+	} else {
+		u.parent[ri] = rj
+	}
+}
@@ -0,0 +1,88 @@
+package iom
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_Levenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"gmail", "gmial", 2},
+	}
+
+	for _, tt := range tests {
+		if got := Levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("Levenshtein(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func Test_NormalizeFuzzy(t *testing.T) {
+	if got := NormalizeFuzzy("  Hello,   World! "); got != "hello world" {
+		t.Errorf("NormalizeFuzzy() = %q, want %q", got, "hello world")
+	}
+}
+
+func Test_FuzzyClusters(t *testing.T) {
+	in := []string{
+		"The quick brown fox jumps over the lazy dog",
+		"something else entirely",
+		"The quick brown fox jumps over the lazy dog.",
+		"the quick brown fox jumps over teh lazy dog",
+	}
+	want := [][]int{{0, 2, 3}, {1}}
+
+	for _, method := range []string{FuzzyMinHash, FuzzyLevenshtein} {
+		opts := DefaultFuzzyOptions()
+		opts.Method = method
+		opts.Threshold = 0.75
+
+		got, err := FuzzyClusters(in, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("FuzzyClusters(%s) = %v, want %v", method, got, want)
+		}
+	}
+}
+
+func Test_FuzzyClustersManyDuplicates(t *testing.T) {
+	var in []string
+	for i := 0; i < 20000; i++ {
+		in = append(in, "Acme, Inc.", "ACME INC")
+	}
+	in = append(in, "Globex Corp", "acme incc")
+
+	for _, method := range []string{FuzzyMinHash, FuzzyLevenshtein} {
+		opts := DefaultFuzzyOptions()
+		opts.Method = method
+		opts.Threshold = 0.7
+
+		got, err := FuzzyClusters(in, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 2 || len(got[0]) != 40001 || !reflect.DeepEqual(got[1], []int{40000}) {
+			t.Errorf("FuzzyClusters(%s) = %d clusters, want the duplicates and near duplicate apart from Globex", method, len(got))
+		}
+	}
+}
+
+func Test_RemoveFuzzyDuplicates(t *testing.T) {
+	in := []string{"Acme, Inc.", "acme inc", "Globex Corp", "ACME INC"}
+
+	n, got, err := RemoveFuzzyDuplicates(in, DefaultFuzzyOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || !reflect.DeepEqual(got, []string{"Acme, Inc.", "Globex Corp"}) {
+		t.Errorf("RemoveFuzzyDuplicates() = %v, %v", n, got)
+	}
+}
@@ -0,0 +1,226 @@
+package iom
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Aggregate functions
+const (
+	AggCount    = "count"
+	AggDistinct = "distinct"
+	AggMin      = "min"
+	AggMax      = "max"
+	AggSum      = "sum"
+	AggConcat   = "concat"
+)
+
+// Aggregate is an aggregate function over one field of every line in a group. Count ignores the
+// field
+type Aggregate struct {
+	Func  string
+	Field int
+}
+
+// ParseAggregates parses comma separated aggregates such as "count,sum:2,concat:1"
+func ParseAggregates(spec string) ([]Aggregate, error) {
+	var result []Aggregate
+	for _, v := range strings.Split(spec, ",") {
+		v = strings.TrimSpace(v)
+		fn, field, hasField := strings.Cut(v, ":")
+
+		switch fn {
+		case AggCount:
+			result = append(result, Aggregate{Func: fn})
+			continue
+		case AggDistinct, AggMin, AggMax, AggSum, AggConcat:
+		default:
+			return nil, fmt.Errorf("parse aggregates: unknown aggregate %q", v)
+		}
+
+		if !hasField {
+			return nil, fmt.Errorf("parse aggregates: %s requires a field id, e.g. %s:1", fn, fn)
+		}
+		id, err := strconv.Atoi(field)
+		if err != nil || id < 0 {
+			return nil, fmt.Errorf("parse aggregates: invalid field id in %q", v)
+		}
+		result = append(result, Aggregate{Func: fn, Field: id})
+	}
+
+	return result, nil
+}
+
+// GroupOptions configures GroupBy
+type GroupOptions struct {
+	// Key selects the fields to group by, its Delim also splits the aggregated fields
+	Key        KeySelector
+	Aggregates []Aggregate
+	// Sep separates values joined by concat
+	Sep string
+}
+
+type aggState struct {
+	count    int
+	distinct map[string]struct{}
+	numeric  bool
+	minNum   float64
+	maxNum   float64
+	minStr   string
+	maxStr   string
+	sum      float64
+	values   []string
+}
+
+func (s *aggState) add(v string) {
+	if s.count == 0 {
+		s.numeric = true
+		s.minStr, s.maxStr = v, v
+	}
+	s.count++
+
+	if v < s.minStr {
+		s.minStr = v
+	}
+	if v > s.maxStr {
+		s.maxStr = v
+	}
+
+	if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil && s.numeric {
+		if s.count == 1 || f < s.minNum {
+			s.minNum = f
+		}
+		if s.count == 1 || f > s.maxNum {
+			s.maxNum = f
+		}
+	} else {
+		s.numeric = false
+	}
+}
+
+// GroupBy groups lines by key and returns one line per group, in order of first occurrence: the
+// key followed by each aggregate. Min and max compare numerically when every value in the group is
+// a number. Lines without the key or an aggregated field, or with a non-numeric value to sum such
+// as a header line, are skipped and counted
+func GroupBy(lines []string, opts GroupOptions) ([]string, int, error) {
+	if opts.Key.IsWholeLine() {
+		return nil, 0, fmt.Errorf("group by: a key field is required")
+	}
+
+	var order []string
+	groups := make(map[string][]*aggState)
+	skipped := 0
+
+	for _, line := range lines {
+		key, ok := opts.Key.Key(line)
+		if !ok {
+			skipped++
+			continue
+		}
+
+		fields := strings.Split(line, opts.Key.Delim)
+		invalid := false
+		for _, a := range opts.Aggregates {
+			if a.Func != AggCount && a.Field >= len(fields) {
+				invalid = true
+			} else if a.Func == AggSum {
+				if _, err := strconv.ParseFloat(strings.TrimSpace(fields[a.Field]), 64); err != nil {
+					invalid = true
+				}
+			}
+		}
+		if invalid {
+			skipped++
+			continue
+		}
+
+		states, ok := groups[key]
+		if !ok {
+			states = make([]*aggState, len(opts.Aggregates))
+			for i := range states {
+				states[i] = &aggState{distinct: make(map[string]struct{})}
+			}
+			groups[key] = states
+			order = append(order, key)
+		}
+
+		for i, a := range opts.Aggregates {
+			s := states[i]
+			if a.Func == AggCount {
+				s.count++
+				continue
+			}
+
+			v := fields[a.Field]
+			switch a.Func {
+			case AggDistinct:
+				s.distinct[v] = struct{}{}
+			case AggMin, AggMax:
+				s.add(v)
+			case AggSum:
+				f, _ := strconv.ParseFloat(strings.TrimSpace(v), 64)
+				s.sum += f
+			case AggConcat:
+				s.values = append(s.values, v)
+			}
+		}
+	}
+
+	result := make([]string, 0, len(order))
+	for _, key := range order {
+		out := []string{opts.Key.Format(key)}
+		for i, a := range opts.Aggregates {
+			s := groups[key][i]
+			switch a.Func {
+			case AggCount:
+				out = append(out, strconv.Itoa(s.count))
+			case AggDistinct:
+				out = append(out, strconv.Itoa(len(s.distinct)))
+			case AggMin:
+				if s.numeric {
+					out = append(out, formatFloat(s.minNum))
+				} else {
+					out = append(out, s.minStr)
+				}
+			case AggMax:
+				if s.numeric {
+					out = append(out, formatFloat(s.maxNum))
+				} else {
+					out = append(out, s.maxStr)
+				}
+			case AggSum:
+				out = append(out, formatFloat(s.sum))
+			case AggConcat:
+				out = append(out, strings.Join(s.values, opts.Sep))
+			}
+		}
+		result = append(result, strings.Join(out, opts.Key.Delim))
+	}
+
+	return result, skipped, nil
+}
+
+// GroupByFile groups the lines of a file and returns the number of groups and skipped lines
+func GroupByFile(src, dst string, opts GroupOptions) (int, int, error) {
+	lines, style, err := ReadFileStyle(src)
+	if err != nil {
+		return 0, 0, fmt.Errorf("group by file: %w", err)
+	}
+
+	result, skipped, err := GroupBy(lines, opts)
+	if err != nil {
+		return 0, skipped, fmt.Errorf("group by file: %w", err)
+	}
+
+	err = WriteFileStyle(dst, result, style)
+	if err != nil {
+		return 0, skipped, fmt.Errorf("group by file: %w", err)
+	}
+
+	return len(result), skipped, nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
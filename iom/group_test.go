@@ -0,0 +1,62 @@
+package iom
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_GroupBy(t *testing.T) {
+	lines := []string{
+		"nyc,ann,10",
+		"sf,bob,3.5",
+		"nyc,cid,9",
+		"nyc,ann,100",
+		"short",
+	}
+
+	aggs, err := ParseAggregates("count,distinct:1,min:2,max:2,sum:2,concat:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, skipped, err := GroupBy(lines, GroupOptions{
+		Key:        KeySelector{Delim: ",", IDs: []int{0}},
+		Aggregates: aggs,
+		Sep:        "|",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"nyc,3,2,9,100,119,ann|cid|ann",
+		"sf,1,1,3.5,3.5,3.5,bob",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupBy() = %v, want %v", got, want)
+	}
+	if skipped != 1 {
+		t.Errorf("GroupBy() skipped = %v, want 1", skipped)
+	}
+}
+
+func Test_GroupBy_NonNumericSum(t *testing.T) {
+	got, skipped, err := GroupBy([]string{"city,amount", "a,1", "a,x", "a,2"}, GroupOptions{
+		Key:        KeySelector{Delim: ",", IDs: []int{0}},
+		Aggregates: []Aggregate{{Func: AggCount}, {Func: AggSum, Field: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a,2,3"}; !reflect.DeepEqual(got, want) || skipped != 2 {
+		t.Errorf("GroupBy() = %v, %v, want %v, 2", got, skipped, want)
+	}
+}
+
+func Test_ParseAggregates_Invalid(t *testing.T) {
+	for _, spec := range []string{"median:1", "sum", "min:x"} {
+		if _, err := ParseAggregates(spec); err == nil {
+			t.Errorf("ParseAggregates(%q) expected error", spec)
+		}
+	}
+}
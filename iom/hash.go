@@ -0,0 +1,173 @@
+package iom
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// Hash algorithms accepted by NewHasher
+const (
+	HashMD5        = "md5"
+	HashSHA1       = "sha1"
+	HashSHA256     = "sha256"
+	HashHMACSHA256 = "hmac-sha256"
+)
+
+// HashAlgorithms returns the names of the hash algorithms accepted by NewHasher
+func HashAlgorithms() []string {
+	return []string{HashMD5, HashSHA1, HashSHA256, HashHMACSHA256}
+}
+
+// Hasher hashes strings to lower case hex after normalizing them
+type Hasher struct {
+	Normalize TransformChain
+	newHash   func() hash.Hash
+}
+
+// NewHasher returns a Hasher for an algorithm. The secret is required by HMAC algorithms and
+// rejected by the others
+func NewHasher(algo string, secret []byte, normalize TransformChain) (*Hasher, error) {
+	h := &Hasher{Normalize: normalize}
+	switch strings.ToLower(algo) {
+	case HashMD5:
+		h.newHash = md5.New
+	case HashSHA1:
+		h.newHash = sha1.New
+	case HashSHA256:
+		h.newHash = sha256.New
+	case HashHMACSHA256:
+		if len(secret) == 0 {
+			return nil, errors.New("new hasher: hmac-sha256 requires a secret")
+		}
+		h.newHash = func() hash.Hash { return hmac.New(sha256.New, secret) }
+		return h, nil
+	default:
+		return nil, fmt.Errorf("new hasher: unknown algorithm %q, expected one of %s", algo, strings.Join(HashAlgorithms(), ", "))
+	}
+
+	if len(secret) > 0 {
+		return nil, fmt.Errorf("new hasher: %s does not use a secret", algo)
+	}
+	return h, nil
+}
+
+// Hash normalizes s and returns its hash as lower case hex
+func (h *Hasher) Hash(s string) string {
+	hh := h.newHash()
+	hh.Write([]byte(h.Normalize.Apply(s)))
+	return hex.EncodeToString(hh.Sum(nil))
+}
+
+// HashOptions configures HashLines
+type HashOptions struct {
+	Hasher *Hasher
+	// Key selects the fields to hash, the whole line by default
+	Key KeySelector
+	// Append writes the line followed by Delim and the hash instead of only the hash
+	Append bool
+	Delim  string
+}
+
+// HashLines hashes every line, or its key fields joined by the key's delimiter. Lines without the
+// selected fields are skipped and counted
+func HashLines(lines []string, opts HashOptions) ([]string, int) {
+	var result []string
+	var skipped int
+	for _, line := range lines {
+		k, ok := opts.Key.Key(line)
+		if !ok {
+			skipped++
+			continue
+		}
+
+		h := opts.Hasher.Hash(opts.Key.Format(k))
+		if opts.Append {
+			h = line + opts.Delim + h
+		}
+		result = append(result, h)
+	}
+	return result, skipped
+}
+
+// HashFile hashes the lines of src into dst. It returns the number of written and skipped lines
+func HashFile(src, dst string, opts HashOptions) (int, int, error) {
+	lines, style, err := ReadFileStyle(src)
+	if err != nil {
+		return 0, 0, fmt.Errorf("hash file: %w", err)
+	}
+
+	result, skipped := HashLines(lines, opts)
+
+	err = WriteFileStyle(dst, result, style)
+	if err != nil {
+		return 0, 0, fmt.Errorf("hash file: %w", err)
+	}
+
+	return len(result), skipped, nil
+}
+
+// ReadHashesFile reads a file of hex hashes into a set, lower cased and trimmed
+func ReadHashesFile(file string) (map[string]struct{}, error) {
+	lines, err := ReadConfigFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read hashes file: %w", err)
+	}
+
+	m := make(map[string]struct{}, len(lines))
+	for _, line := range lines {
+		line = strings.ToLower(strings.TrimSpace(line))
+		if line != "" {
+			m[line] = struct{}{}
+		}
+	}
+	return m, nil
+}
+
+// DiffHashed returns the lines whose hashed key, hashed as HashLines does, is not in hashes. Lines
+// without the selected fields are skipped and counted separately
+func DiffHashed(hashes map[string]struct{}, lines []string, key KeySelector, h *Hasher) (int, int, []string) {
+	var result []string
+	var skipped int
+	for _, line := range lines {
+		k, ok := key.Key(line)
+		if !ok {
+			skipped++
+			continue
+		}
+		if _, ok := hashes[h.Hash(key.Format(k))]; !ok {
+			result = append(result, line)
+		}
+	}
+
+	return len(result), skipped, result
+}
+
+// DiffFilesHashed returns the lines of src whose hashed key is not among the hashes in
+// hashedBase. The full lines of src are written to out
+func DiffFilesHashed(hashedBase, src, out string, key KeySelector, h *Hasher) (int, int, error) {
+	hashes, err := ReadHashesFile(hashedBase)
+	if err != nil {
+		return 0, 0, fmt.Errorf("diff files hashed: %w", err)
+	}
+
+	lines, style, err := ReadFileStyle(src)
+	if err != nil {
+		return 0, 0, fmt.Errorf("diff files hashed: %w", err)
+	}
+
+	n, skipped, result := DiffHashed(hashes, lines, key, h)
+
+	err = WriteFileStyle(out, result, style)
+	if err != nil {
+		return 0, 0, fmt.Errorf("diff files hashed: %w", err)
+	}
+
+	return n, skipped, nil
+}
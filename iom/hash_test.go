@@ -0,0 +1,89 @@
+package iom
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_Hasher(t *testing.T) {
+	tests := []struct {
+		algo   string
+		secret string
+		want   string
+	}{
+		{HashMD5, "", "5d41402abc4b2a76b9719d911017c592"},
+		{HashSHA1, "", "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"},
+		{HashSHA256, "", "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{HashHMACSHA256, "key", "9307b3b915efb5171ff14d8cb55fbcc798c6c0ef1456d66ded1a6aa723a58b7b"},
+	}
+
+	for _, tt := range tests {
+		h, err := NewHasher(tt.algo, []byte(tt.secret), nil)
+		if err != nil {
+			t.Fatalf("NewHasher(%q) error = %v", tt.algo, err)
+		}
+		if got := h.Hash("hello"); got != tt.want {
+			t.Errorf("%s Hash() = %q, want %q", tt.algo, got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct{ algo, secret string }{{"crc32", ""}, {HashHMACSHA256, ""}, {HashMD5, "key"}} {
+		if _, err := NewHasher(tt.algo, []byte(tt.secret), nil); err == nil {
+			t.Errorf("NewHasher(%q, %q) expected error", tt.algo, tt.secret)
+		}
+	}
+}
+
+func Test_HashLines(t *testing.T) {
+	normalize, err := ParseTransforms([]string{"trim", "lower"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := NewHasher(HashMD5, nil, normalize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, _ := ParseKeySelector(",", "1")
+	got, skipped := HashLines([]string{"a, Hello ", "b,hello", "c"}, HashOptions{Hasher: h, Key: key, Append: true, Delim: ","})
+
+	want := []string{"a, Hello ,5d41402abc4b2a76b9719d911017c592", "b,hello,5d41402abc4b2a76b9719d911017c592"}
+	if !reflect.DeepEqual(got, want) || skipped != 1 {
+		t.Errorf("HashLines() = %v, %d, want %v, 1", got, skipped, want)
+	}
+}
+
+func Test_DiffHashed(t *testing.T) {
+	normalize, _ := ParseTransforms([]string{"lower"})
+	h, err := NewHasher(HashSHA256, nil, normalize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hashes := map[string]struct{}{h.Hash("bob@example.com"): {}}
+	n, skipped, got := DiffHashed(hashes, []string{"Bob@Example.com", "alice@example.com"}, KeySelector{}, h)
+
+	if n != 1 || skipped != 0 || !reflect.DeepEqual(got, []string{"alice@example.com"}) {
+		t.Errorf("DiffHashed() = %d, %d, %v", n, skipped, got)
+	}
+}
+
+func Test_HashLines_MultiFieldKey(t *testing.T) {
+	h, err := NewHasher(HashSHA256, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := ParseKeySelector(",", "0,1")
+
+	// the fields are hashed as written, joined by the delimiter
+	want := "1eb7c54d52831bbfe8942af0b1c56b7409523a59ed6ca99c1174fef7eb32c1b5" // sha256("a,b")
+	got, _ := HashLines([]string{"a,b,c"}, HashOptions{Hasher: h, Key: key})
+	if !reflect.DeepEqual(got, []string{want}) {
+		t.Errorf("HashLines() = %v, want %v", got, []string{want})
+	}
+
+	n, _, _ := DiffHashed(map[string]struct{}{want: {}}, []string{"a,b,x"}, key, h)
+	if n != 0 {
+		t.Errorf("DiffHashed() = %d, want 0", n)
+	}
+}
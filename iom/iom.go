@@ -1,7 +1,7 @@
 package iom
 
 import (
-	"bufio"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -46,36 +46,59 @@ func AppendSuffixToFilename(filename, suffix string) string {
 
 // ReadFile reads a file and returns the contents as a []string
 func ReadFile(file string) ([]string, error) {
-	f, err := os.Open(file)
+	lines, _, err := ReadFileStyle(file)
+	return lines, err
+}
+
+// ReadFileStyle reads a file like ReadFile and also returns its line style, for WriteFileStyle to
+// preserve in files written from it
+func ReadFileStyle(file string) ([]string, *LineStyle, error) {
+	r, err := OpenLineReader(file)
 	if err != nil {
-		return nil, fmt.Errorf("reading file: %w", err)
+		return nil, nil, err
 	}
-	defer f.Close()
+	defer r.Close()
+
+	var lines []string
+	for line, ok := r.Next(); ok; line, ok = r.Next() {
+		lines = append(lines, line)
+	}
+
+	return lines, r.Style(), r.Err()
+}
+
+// ReadConfigFile reads a file that configures a command rather than being processed by it, such as
+// a script or a list of domains, one line per newline. Unlike ReadFile it ignores
+// FileOptions.RecordSep and MaxLineLength, which describe the processed files
+func ReadConfigFile(file string) ([]string, error) {
+	r, err := openLineReader(file, &recordSep{kind: sepNewline}, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
 
 	var lines []string
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+	for line, ok := r.Next(); ok; line, ok = r.Next() {
+		lines = append(lines, line)
 	}
 
-	return lines, scanner.Err()
+	return lines, r.Err()
 }
 
 // ReadFileToMap reads a file and returns the contents as a map[string]struct{}
 func ReadFileToMap(file string) (map[string]struct{}, error) {
-	f, err := os.Open(file)
+	r, err := OpenLineReader(file)
 	if err != nil {
-		return nil, fmt.Errorf("reading file: %w", err)
+		return nil, err
 	}
-	defer f.Close()
+	defer r.Close()
 
 	m := make(map[string]struct{})
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		m[scanner.Text()] = struct{}{}
+	for line, ok := r.Next(); ok; line, ok = r.Next() {
+		m[line] = struct{}{}
 	}
 
-	return m, scanner.Err()
+	return m, r.Err()
 }
 
 // ReadDirToMap reads a directory and returns the contents as a map[string]struct{}
@@ -101,50 +124,66 @@ func ReadDirToMap(dir string) (map[string]struct{}, error) {
 
 // WriteFile writes a []string to a file
 func WriteFile(file string, lines []string) error {
-	f, err := os.Create(file)
+	return WriteFileStyle(file, lines, nil)
+}
+
+// WriteFileStyle writes a []string to a file in the line style of the input file it comes from,
+// unless FileOptions override it
+func WriteFileStyle(file string, lines []string, style *LineStyle) error {
+	w, err := CreateLineWriter(file, style)
 	if err != nil {
+		return err
+	}
+
+	if err = writeLines(w, lines); err != nil {
 		return fmt.Errorf("write file: %w", err)
 	}
+	return nil
+}
 
-	w := bufio.NewWriter(f)
-	sb := strings.Builder{}
+// writeLines writes lines and closes w
+func writeLines(w *LineWriter, lines []string) error {
 	for _, line := range lines {
-		sb.WriteString(line + "\n")
-		if err != nil {
-			return fmt.Errorf("write file: %w", err)
+		if err := w.Write(line); err != nil {
+			w.Close()
+			return err
 		}
 	}
+	return w.Close()
+}
 
-	_, err = w.WriteString(sb.String())
-	w.Flush()
-	return err
+// WriteJSON writes v to a file as indented JSON
+func WriteJSON(file string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("write json: %w", err)
+	}
+
+	err = WriteFile(file, []string{string(b)})
+	if err != nil {
+		return fmt.Errorf("write json: %w", err)
+	}
+
+	return nil
 }
 
 // AppendFile appends a []string to a file
 func AppendFile(file string, lines []string) error {
-	if !FileExists(file) {
-		err := CreateFile(file)
-		if err != nil {
-			return fmt.Errorf("append file: %w", err)
-		}
-	}
+	return AppendFileStyle(file, lines, nil)
+}
 
-	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0644)
+// AppendFileStyle appends a []string to a file in the line style of the input file it comes from,
+// unless FileOptions override it
+func AppendFileStyle(file string, lines []string, style *LineStyle) error {
+	w, err := AppendLineWriter(file, style)
 	if err != nil {
-		return fmt.Errorf("append file: %w", err)
+		return err
 	}
 
-	w := bufio.NewWriter(f)
-	sb := strings.Builder{}
-	for _, line := range lines {
-		sb.WriteString(line + "\n")
-		if err != nil {
-			return fmt.Errorf("append file: %w", err)
-		}
+	if err = writeLines(w, lines); err != nil {
+		return fmt.Errorf("append file: %w", err)
 	}
-
-	_, err = w.WriteString(sb.String())
-	return err
+	return nil
 }
 
 // ReadDir reads a directory and returns the contents as a []string
@@ -342,14 +381,14 @@ func ShuffleStrings(s []string) {
 
 // ShuffleFile shuffles a file
 func ShuffleFile(src, dst string) error {
-	lines, err := ReadFile(src)
+	lines, style, err := ReadFileStyle(src)
 	if err != nil {
 		return fmt.Errorf("shuffle file: %w", err)
 	}
 
 	ShuffleStrings(lines)
 
-	err = WriteFile(dst, lines)
+	err = WriteFileStyle(dst, lines, style)
 	if err != nil {
 		return fmt.Errorf("shuffle file: %w", err)
 	}
@@ -357,16 +396,29 @@ func ShuffleFile(src, dst string) error {
 	return nil
 }
 
+// lineIndex records the first occurrence of each line. It decides which lines are duplicates for
+// every dedupe and for FindDuplicates, so they always agree
+type lineIndex map[string]int
+
+// first returns the value recorded for the first occurrence of a line and true, or records v for
+// a new line and returns false
+func (x lineIndex) first(line string, v int) (int, bool) {
+	if w, ok := x[line]; ok {
+		return w, true
+	}
+	x[line] = v
+	return v, false
+}
+
 // RemoveDuplicates removes duplicate lines from a []string
 func RemoveDuplicates(s []string) (int, []string) {
 	var result []string
-	seen := make(map[string]bool)
+	seen := make(lineIndex)
 
 	dupes := 0
 	for _, v := range s {
-		if !seen[v] {
+		if _, dup := seen.first(v, 0); !dup {
 			result = append(result, v)
-			seen[v] = true
 		} else {
 			dupes++
 		}
@@ -379,14 +431,14 @@ func RemoveDuplicates(s []string) (int, []string) {
 func RemoveDuplicatesFile(src, dst string) (int, error) {
 	var n int
 
-	lines, err := ReadFile(src)
+	lines, style, err := ReadFileStyle(src)
 	if err != nil {
 		return n, fmt.Errorf("remove duplicates file: %w", err)
 	}
 
 	n, lines = RemoveDuplicates(lines)
 
-	err = WriteFile(dst, lines)
+	err = WriteFileStyle(dst, lines, style)
 	if err != nil {
 		return n, fmt.Errorf("remove duplicates file: %w", err)
 	}
@@ -457,7 +509,7 @@ func CountFileLines(path string) (int, error) {
 
 // ChunkFile splits a file into chunks
 func ChunkByLinesFile(src, dst string, chunkSize int) (int, error) {
-	lines, err := ReadFile(src)
+	lines, style, err := ReadFileStyle(src)
 	if err != nil {
 		return 0, fmt.Errorf("chunk file: %w", err)
 	}
@@ -465,7 +517,7 @@ func ChunkByLinesFile(src, dst string, chunkSize int) (int, error) {
 
 	for i, chunk := range chunks {
 		fname := AppendSuffixToFilename(dst, "-"+strconv.Itoa(i+1))
-		err = WriteFile(fname, chunk)
+		err = WriteFileStyle(fname, chunk, style)
 		if err != nil {
 			return 0, fmt.Errorf("chunk file: %w", err)
 		}
@@ -511,17 +563,111 @@ func DiffFiles(src1, src2, out string) (int, error) {
 		return 0, fmt.Errorf("diff files: %w", err)
 	}
 
-	lines, err := ReadFile(src2)
+	lines, style, err := ReadFileStyle(src2)
 	if err != nil {
 		return 0, fmt.Errorf("diff files: %w", err)
 	}
 
 	n, result := Diff(baseMap, lines)
 
-	err = WriteFile(out, result)
+	err = WriteFileStyle(out, result, style)
 	if err != nil {
 		return 0, fmt.Errorf("diff files: %w", err)
 	}
 
 	return n, nil
 }
+
+// RemoveDuplicatesAcross removes duplicate lines across multiple []string, where the first
+// occurrence of a line wins. It returns the deduped []string for each input and a matrix where
+// lost[i][j] is the number of lines input i lost to input j (lost[i][i] counts duplicates within i)
+func RemoveDuplicatesAcross(s [][]string) ([][]string, [][]int) {
+	result := make([][]string, len(s))
+	lost := make([][]int, len(s))
+	owner := make(lineIndex)
+
+	for i, lines := range s {
+		lost[i] = make([]int, len(s))
+		for _, v := range lines {
+			if j, dup := owner.first(v, i); dup {
+				lost[i][j]++
+				continue
+			}
+			result[i] = append(result[i], v)
+		}
+	}
+
+	return result, lost
+}
+
+// RemoveDuplicatesAcrossFiles removes duplicate lines across files, writing each src's surviving
+// lines to the dst at the same index. Files earlier in srcs win over later ones
+func RemoveDuplicatesAcrossFiles(srcs, dsts []string) ([][]int, error) {
+	if len(srcs) != len(dsts) {
+		return nil, fmt.Errorf("remove duplicates across files: %d sources but %d destinations", len(srcs), len(dsts))
+	}
+
+	var sets [][]string
+	var styles []*LineStyle
+	for _, src := range srcs {
+		lines, style, err := ReadFileStyle(src)
+		if err != nil {
+			return nil, fmt.Errorf("remove duplicates across files: %w", err)
+		}
+		sets = append(sets, lines)
+		styles = append(styles, style)
+	}
+
+	result, lost := RemoveDuplicatesAcross(sets)
+	for i, dst := range dsts {
+		err := WriteFileStyle(dst, result[i], styles[i])
+		if err != nil {
+			return nil, fmt.Errorf("remove duplicates across files: %w", err)
+		}
+	}
+
+	return lost, nil
+}
+
+// DiffByKey returns the lines whose key is not in src1. Lines without the selected fields are
+// skipped and counted separately
+func DiffByKey(src1 map[string]struct{}, src2 []string, key KeySelector) (int, int, []string) {
+	var result []string
+	var n, skipped int
+	for _, line := range src2 {
+		k, ok := key.Key(line)
+		if !ok {
+			skipped++
+			continue
+		}
+		if _, ok := src1[k]; !ok {
+			result = append(result, line)
+			n++
+		}
+	}
+
+	return n, skipped, result
+}
+
+// DiffFilesByKey returns the lines of src2 whose key is not among the keys of src1. The full
+// lines of src2 are written to out
+func DiffFilesByKey(src1, src2, out string, baseKey, key KeySelector) (int, int, error) {
+	baseMap, err := ReadFileToKeyMap(src1, baseKey)
+	if err != nil {
+		return 0, 0, fmt.Errorf("diff files by key: %w", err)
+	}
+
+	lines, style, err := ReadFileStyle(src2)
+	if err != nil {
+		return 0, 0, fmt.Errorf("diff files by key: %w", err)
+	}
+
+	n, skipped, result := DiffByKey(baseMap, lines, key)
+
+	err = WriteFileStyle(out, result, style)
+	if err != nil {
+		return 0, 0, fmt.Errorf("diff files by key: %w", err)
+	}
+
+	return n, skipped, nil
+}
@@ -1,6 +1,7 @@
 package iom
 
 import (
+	"path/filepath"
 	"reflect"
 	"testing"
 )
@@ -237,3 +238,70 @@ func Test_Diff(t *testing.T) {
 		t.Errorf("Diff() = %v, want %v", diff[0], "four")
 	}
 }
+
+func Test_RemoveDuplicatesAcross(t *testing.T) {
+	in := [][]string{
+		{"one", "two", "two"},
+		{"two", "three", "one"},
+		{"three", "four"},
+	}
+
+	got, lost := RemoveDuplicatesAcross(in)
+
+	want := [][]string{{"one", "two"}, {"three"}, {"four"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RemoveDuplicatesAcross() = %v, want %v", got, want)
+	}
+
+	wantLost := [][]int{{1, 0, 0}, {2, 0, 0}, {0, 1, 0}}
+	if !reflect.DeepEqual(lost, wantLost) {
+		t.Errorf("RemoveDuplicatesAcross() lost = %v, want %v", lost, wantLost)
+	}
+}
+
+func Test_DiffByKey(t *testing.T) {
+	key, err := ParseKeySelector(",", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := map[string]struct{}{"a@example.com": {}}
+	lines := []string{"1,a@example.com,x", "2,b@example.com,y", "3"}
+
+	n, skipped, diff := DiffByKey(base, lines, key)
+	if n != 1 || skipped != 1 {
+		t.Errorf("DiffByKey() = %v, %v, want 1, 1", n, skipped)
+	}
+
+	if !reflect.DeepEqual(diff, []string{"2,b@example.com,y"}) {
+		t.Errorf("DiffByKey() = %v, want %v", diff, []string{"2,b@example.com,y"})
+	}
+}
+
+func Test_DiffFilesByKeyMixedDelims(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.txt")
+	src := filepath.Join(dir, "src.txt")
+	out := filepath.Join(dir, "out.txt")
+	if err := WriteFile(base, []string{"a;b;x", "c;d;y"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(src, []string{"a,b,1", "c,e,2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	baseKey, _ := ParseKeySelector(";", "0,1")
+	key, _ := ParseKeySelector(",", "0,1")
+	n, skipped, err := DiffFilesByKey(base, src, out, baseKey, key)
+	if err != nil || n != 1 || skipped != 0 {
+		t.Fatalf("DiffFilesByKey() = %v, %v, %v, want 1, 0, nil", n, skipped, err)
+	}
+
+	got, err := ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"c,e,2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffFilesByKey() wrote %v, want %v", got, want)
+	}
+}
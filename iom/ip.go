@@ -0,0 +1,502 @@
+package iom
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ipClasses are the address ranges accepted by IPOptions.Drop. "bogon" is every range that should
+// never appear as a public address
+var ipClasses = map[string][]netip.Prefix{
+	"private": mustParsePrefixes(
+		"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7",
+	),
+	"loopback": mustParsePrefixes(
+		"127.0.0.0/8", "::1/128",
+	),
+	"linklocal": mustParsePrefixes(
+		"169.254.0.0/16", "fe80::/10",
+	),
+	"multicast": mustParsePrefixes(
+		"224.0.0.0/4", "ff00::/8",
+	),
+	"reserved": mustParsePrefixes(
+		"0.0.0.0/8", "100.64.0.0/10", "192.0.0.0/24", "192.0.2.0/24", "198.18.0.0/15",
+		"198.51.100.0/24", "203.0.113.0/24", "240.0.0.0/4", "::/128", "::ffff:0:0/96",
+		"100::/64", "2001:db8::/32",
+	),
+}
+
+func init() {
+	var bogon []netip.Prefix
+	for _, ps := range ipClasses {
+		bogon = append(bogon, ps...)
+	}
+	ipClasses["bogon"] = bogon
+}
+
+func mustParsePrefixes(s ...string) []netip.Prefix {
+	result := make([]netip.Prefix, len(s))
+	for i, v := range s {
+		result[i] = netip.MustParsePrefix(v)
+	}
+	return result
+}
+
+// IPClasses returns the names of the address classes accepted by IPOptions.Drop
+func IPClasses() []string {
+	return []string{"private", "loopback", "linklocal", "multicast", "reserved", "bogon"}
+}
+
+// ParseAddr parses an IPv4 or IPv6 address. Leading zeros in IPv4 octets are read as decimal and
+// IPv6 zones are dropped
+func ParseAddr(s string) (netip.Addr, error) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '%'); i >= 0 {
+		s = s[:i]
+	}
+
+	if !strings.Contains(s, ":") {
+		parts := strings.Split(s, ".")
+		if len(parts) != 4 {
+			return netip.Addr{}, fmt.Errorf("invalid IP address %q", s)
+		}
+		var b [4]byte
+		for i, p := range parts {
+			n, err := strconv.ParseUint(p, 10, 8)
+			if err != nil || p == "" || len(p) > 3 {
+				return netip.Addr{}, fmt.Errorf("invalid IP address %q", s)
+			}
+			b[i] = byte(n)
+		}
+		return netip.AddrFrom4(b), nil
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return addr.Unmap(), nil
+}
+
+// ParseIPEntry parses an address, a CIDR or a start-end range into prefixes. An address is a
+// single address prefix, a CIDR is masked to its network address and a range is split into the
+// fewest prefixes covering it
+func ParseIPEntry(s string) ([]netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+
+	if from, to, ok := strings.Cut(s, "-"); ok {
+		start, err := ParseAddr(from)
+		if err != nil {
+			return nil, err
+		}
+		end, err := ParseAddr(to)
+		if err != nil {
+			return nil, err
+		}
+		if start.Is4() != end.Is4() || end.Less(start) {
+			return nil, fmt.Errorf("invalid IP range %q", s)
+		}
+		return rangeToPrefixes(start, end), nil
+	}
+
+	if addr, bitsStr, ok := strings.Cut(s, "/"); ok {
+		a, err := ParseAddr(addr)
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.Atoi(bitsStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", s)
+		}
+		p, err := a.Prefix(n)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", s)
+		}
+		return []netip.Prefix{p}, nil
+	}
+
+	a, err := ParseAddr(s)
+	if err != nil {
+		return nil, err
+	}
+	return []netip.Prefix{netip.PrefixFrom(a, a.BitLen())}, nil
+}
+
+// FormatPrefix formats a prefix, writing single address prefixes as a plain address
+func FormatPrefix(p netip.Prefix) string {
+	if p.Bits() == p.Addr().BitLen() {
+		return p.Addr().String()
+	}
+	return p.String()
+}
+
+// SortPrefixes sorts prefixes numerically by address, IPv4 before IPv6, then by prefix length
+func SortPrefixes(ps []netip.Prefix) {
+	sort.SliceStable(ps, func(i, j int) bool {
+		if c := ps[i].Addr().Compare(ps[j].Addr()); c != 0 {
+			return c < 0
+		}
+		return ps[i].Bits() < ps[j].Bits()
+	})
+}
+
+// u128 is an address as an unsigned integer, IPv4 addresses use only lo
+type u128 struct {
+	hi, lo uint64
+}
+
+func addrToU128(a netip.Addr) u128 {
+	if a.Is4() {
+		b := a.As4()
+		return u128{lo: uint64(b[0])<<24 | uint64(b[1])<<16 | uint64(b[2])<<8 | uint64(b[3])}
+	}
+	b := a.As16()
+	var v u128
+	for i := 0; i < 8; i++ {
+		v.hi = v.hi<<8 | uint64(b[i])
+		v.lo = v.lo<<8 | uint64(b[i+8])
+	}
+	return v
+}
+
+func u128ToAddr(v u128, is4 bool) netip.Addr {
+	if is4 {
+		return netip.AddrFrom4([4]byte{byte(v.lo >> 24), byte(v.lo >> 16), byte(v.lo >> 8), byte(v.lo)})
+	}
+	var b [16]byte
+	for i := 0; i < 8; i++ {
+		b[i] = byte(v.hi >> (56 - 8*i))
+		b[i+8] = byte(v.lo >> (56 - 8*i))
+	}
+	return netip.AddrFrom16(b)
+}
+
+func (v u128) cmp(o u128) int {
+	switch {
+	case v.hi < o.hi || v.hi == o.hi && v.lo < o.lo:
+		return -1
+	case v == o:
+		return 0
+	}
+	return 1
+}
+
+func (v u128) add(o u128) u128 {
+	lo, carry := bits.Add64(v.lo, o.lo, 0)
+	return u128{hi: v.hi + o.hi + carry, lo: lo}
+}
+
+func (v u128) sub(o u128) u128 {
+	lo, borrow := bits.Sub64(v.lo, o.lo, 0)
+	return u128{hi: v.hi - o.hi - borrow, lo: lo}
+}
+
+// hostMask returns 2^n - 1
+func hostMask(n int) u128 {
+	switch {
+	case n <= 0:
+		return u128{}
+	case n < 64:
+		return u128{lo: 1<<uint(n) - 1}
+	case n < 128:
+		return u128{hi: 1<<uint(n-64) - 1, lo: ^uint64(0)}
+	}
+	return u128{hi: ^uint64(0), lo: ^uint64(0)}
+}
+
+func (v u128) trailingZeros() int {
+	if v.lo != 0 {
+		return bits.TrailingZeros64(v.lo)
+	}
+	if v.hi != 0 {
+		return 64 + bits.TrailingZeros64(v.hi)
+	}
+	return 128
+}
+
+// ipRange is an inclusive range of addresses of one family
+type ipRange struct {
+	from, to u128
+	is4      bool
+}
+
+func prefixToRange(p netip.Prefix) ipRange {
+	p = p.Masked()
+	from := addrToU128(p.Addr())
+	return ipRange{from: from, to: from.add(hostMask(p.Addr().BitLen() - p.Bits())), is4: p.Addr().Is4()}
+}
+
+func rangeToPrefixes(start, end netip.Addr) []netip.Prefix {
+	r := ipRange{from: addrToU128(start), to: addrToU128(end), is4: start.Is4()}
+	return r.prefixes()
+}
+
+// prefixes returns the fewest prefixes covering the range
+func (r ipRange) prefixes() []netip.Prefix {
+	bitLen := 128
+	if r.is4 {
+		bitLen = 32
+	}
+
+	var result []netip.Prefix
+	from := r.from
+	for from.cmp(r.to) <= 0 {
+		size := from.trailingZeros()
+		if size > bitLen {
+			size = bitLen
+		}
+		for size > 0 && from.add(hostMask(size)).cmp(r.to) > 0 {
+			size--
+		}
+		result = append(result, netip.PrefixFrom(u128ToAddr(from, r.is4), bitLen-size))
+
+		last := from.add(hostMask(size))
+		if last == hostMask(bitLen) {
+			break
+		}
+		from = last.add(u128{lo: 1})
+	}
+	return result
+}
+
+// mergeRanges sorts ranges and merges overlapping and adjacent ones
+func mergeRanges(ps []netip.Prefix) []ipRange {
+	ranges := make([]ipRange, len(ps))
+	for i, p := range ps {
+		ranges[i] = prefixToRange(p)
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		if ranges[i].is4 != ranges[j].is4 {
+			return ranges[i].is4
+		}
+		return ranges[i].from.cmp(ranges[j].from) < 0
+	})
+
+	var merged []ipRange
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && merged[n-1].is4 == r.is4 {
+			last := &merged[n-1]
+			if last.to == hostMask(128) || r.from.cmp(last.to.add(u128{lo: 1})) <= 0 {
+				if r.to.cmp(last.to) > 0 {
+					last.to = r.to
+				}
+				continue
+			}
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// AggregatePrefixes merges prefixes into the fewest prefixes covering exactly the same addresses
+func AggregatePrefixes(ps []netip.Prefix) []netip.Prefix {
+	var result []netip.Prefix
+	for _, r := range mergeRanges(ps) {
+		result = append(result, r.prefixes()...)
+	}
+	return result
+}
+
+// SubtractPrefixes returns the fewest prefixes covering the addresses of a that are not in b
+func SubtractPrefixes(a, b []netip.Prefix) []netip.Prefix {
+	remove := mergeRanges(b)
+
+	var result []netip.Prefix
+	for _, r := range mergeRanges(a) {
+		pieces := []ipRange{r}
+		for _, x := range remove {
+			if x.is4 != r.is4 {
+				continue
+			}
+
+			var next []ipRange
+			for _, p := range pieces {
+				if x.to.cmp(p.from) < 0 || x.from.cmp(p.to) > 0 {
+					next = append(next, p)
+					continue
+				}
+				if x.from.cmp(p.from) > 0 {
+					next = append(next, ipRange{from: p.from, to: x.from.sub(u128{lo: 1}), is4: p.is4})
+				}
+				if x.to.cmp(p.to) < 0 {
+					next = append(next, ipRange{from: x.to.add(u128{lo: 1}), to: p.to, is4: p.is4})
+				}
+			}
+			pieces = next
+		}
+
+		for _, p := range pieces {
+			result = append(result, p.prefixes()...)
+		}
+	}
+	return result
+}
+
+// ExpandPrefixes lists every address of the prefixes, failing if there are more than max. A max
+// of 0 is unlimited
+func ExpandPrefixes(ps []netip.Prefix, max int) ([]netip.Addr, error) {
+	var result []netip.Addr
+	for _, p := range ps {
+		p = p.Masked()
+		for a := p.Addr(); a.IsValid() && p.Contains(a); a = a.Next() {
+			if max > 0 && len(result) >= max {
+				return nil, fmt.Errorf("expand prefixes: more than %d addresses", max)
+			}
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+// InIPClass reports whether a prefix overlaps any range of an address class
+func InIPClass(p netip.Prefix, class string) (bool, error) {
+	ranges, ok := ipClasses[class]
+	if !ok {
+		return false, fmt.Errorf("unknown address class %q, expected one of %s", class, strings.Join(IPClasses(), ", "))
+	}
+	for _, r := range ranges {
+		if r.Overlaps(p) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// IPOptions configures ProcessIPs. Steps run in the order of the fields
+type IPOptions struct {
+	// Drop removes entries overlapping any of these address classes
+	Drop []string
+	// Subtract removes these prefixes, producing aggregated output
+	Subtract []netip.Prefix
+	// Aggregate merges entries into the fewest CIDRs
+	Aggregate bool
+	// Expand lists every address of each entry, up to MaxExpand addresses in total (0 is unlimited)
+	Expand    bool
+	MaxExpand int
+	Dedupe    bool
+	Sort      bool
+}
+
+// ProcessIPs canonicalizes every line as an address, CIDR or range and applies opts. It returns
+// the output lines and the rejected lines, each followed by a tab and the reason
+func ProcessIPs(lines []string, opts IPOptions) ([]string, []string, error) {
+	for _, class := range opts.Drop {
+		if _, ok := ipClasses[class]; !ok {
+			return nil, nil, fmt.Errorf("process ips: unknown address class %q", class)
+		}
+	}
+
+	var ps []netip.Prefix
+	var rejected []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		entry, err := ParseIPEntry(line)
+		if err != nil {
+			rejected = append(rejected, line+"\t"+err.Error())
+			continue
+		}
+
+	entries:
+		for _, p := range entry {
+			for _, class := range opts.Drop {
+				if in, _ := InIPClass(p, class); in {
+					rejected = append(rejected, line+"\t"+class)
+					continue entries
+				}
+			}
+			ps = append(ps, p)
+		}
+	}
+
+	if len(opts.Subtract) > 0 {
+		ps = SubtractPrefixes(ps, opts.Subtract)
+	} else if opts.Aggregate {
+		ps = AggregatePrefixes(ps)
+	}
+
+	var result []string
+	if opts.Expand {
+		addrs, err := ExpandPrefixes(ps, opts.MaxExpand)
+		if err != nil {
+			return nil, nil, err
+		}
+		ps = ps[:0]
+		for _, a := range addrs {
+			ps = append(ps, netip.PrefixFrom(a, a.BitLen()))
+		}
+	}
+
+	if opts.Sort {
+		SortPrefixes(ps)
+	}
+	for _, p := range ps {
+		result = append(result, FormatPrefix(p))
+	}
+	if opts.Dedupe {
+		_, result = RemoveDuplicates(result)
+	}
+
+	return result, rejected, nil
+}
+
+// ReadPrefixesFile reads a file of addresses, CIDRs and ranges
+func ReadPrefixesFile(file string) ([]netip.Prefix, error) {
+	lines, err := ReadConfigFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read prefixes file: %w", err)
+	}
+
+	var result []netip.Prefix
+	for n, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		ps, err := ParseIPEntry(line)
+		if err != nil {
+			return nil, fmt.Errorf("read prefixes file: %s:%d: %w", file, n+1, err)
+		}
+		result = append(result, ps...)
+	}
+
+	if len(result) == 0 {
+		return nil, errors.New("read prefixes file: no prefixes in " + file)
+	}
+	return result, nil
+}
+
+// ProcessIPsFile processes the addresses of src into dst, writing rejected lines to rejects
+// unless it is empty. It returns the number of written and rejected lines
+func ProcessIPsFile(src, dst, rejects string, opts IPOptions) (int, int, error) {
+	lines, style, err := ReadFileStyle(src)
+	if err != nil {
+		return 0, 0, fmt.Errorf("process ips file: %w", err)
+	}
+
+	result, rejected, err := ProcessIPs(lines, opts)
+	if err != nil {
+		return 0, 0, fmt.Errorf("process ips file: %w", err)
+	}
+
+	err = WriteFileStyle(dst, result, style)
+	if err != nil {
+		return 0, 0, fmt.Errorf("process ips file: %w", err)
+	}
+
+	if rejects != "" {
+		err = WriteFileStyle(rejects, rejected, style)
+		if err != nil {
+			return 0, 0, fmt.Errorf("process ips file: %w", err)
+		}
+	}
+
+	return len(result), len(rejected), nil
+}
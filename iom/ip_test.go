@@ -0,0 +1,117 @@
+package iom
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func formatPrefixes(ps []netip.Prefix) []string {
+	result := make([]string, len(ps))
+	for i, p := range ps {
+		result[i] = FormatPrefix(p)
+	}
+	return result
+}
+
+func parsePrefixes(t *testing.T, s ...string) []netip.Prefix {
+	t.Helper()
+	var result []netip.Prefix
+	for _, v := range s {
+		ps, err := ParseIPEntry(v)
+		if err != nil {
+			t.Fatalf("ParseIPEntry(%q) error = %v", v, err)
+		}
+		result = append(result, ps...)
+	}
+	return result
+}
+
+func Test_ParseIPEntry(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"010.001.000.255", []string{"10.1.0.255"}},
+		{"2001:0db8:0000:0000:0000:0000:0000:0001", []string{"2001:db8::1"}},
+		{"::ffff:1.2.3.4", []string{"1.2.3.4"}},
+		{"fe80::1%eth0", []string{"fe80::1"}},
+		{"192.168.1.77/24", []string{"192.168.1.0/24"}},
+		{"10.0.0.1-10.0.0.6", []string{"10.0.0.1", "10.0.0.2/31", "10.0.0.4/31", "10.0.0.6"}},
+		{"0.0.0.0-255.255.255.255", []string{"0.0.0.0/0"}},
+	}
+
+	for _, tt := range tests {
+		if got := formatPrefixes(parsePrefixes(t, tt.in)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseIPEntry(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"1.2.3", "256.1.1.1", "1.2.3.4/33", "10.0.0.9-10.0.0.1", "1.2.3.4-::1", "nope"} {
+		if _, err := ParseIPEntry(in); err == nil {
+			t.Errorf("ParseIPEntry(%q) expected error", in)
+		}
+	}
+}
+
+func Test_AggregatePrefixes(t *testing.T) {
+	ps := parsePrefixes(t, "10.0.0.3", "10.0.0.0", "10.0.0.1", "10.0.0.2", "10.0.1.0/24", "10.0.0.128/25",
+		"2001:db8::/33", "2001:db8:8000::/33", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", "10.0.0.2")
+	want := []string{"10.0.0.0/30", "10.0.0.128/25", "10.0.1.0/24", "2001:db8::/32", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"}
+
+	if got := formatPrefixes(AggregatePrefixes(ps)); !reflect.DeepEqual(got, want) {
+		t.Errorf("AggregatePrefixes() = %v, want %v", got, want)
+	}
+}
+
+func Test_SubtractPrefixes(t *testing.T) {
+	a := parsePrefixes(t, "10.0.0.0/24", "2001:db8::/126")
+	b := parsePrefixes(t, "10.0.0.0/25", "10.0.0.200", "2001:db8::3")
+	want := []string{"10.0.0.128/26", "10.0.0.192/29", "10.0.0.201", "10.0.0.202/31", "10.0.0.204/30",
+		"10.0.0.208/28", "10.0.0.224/27", "2001:db8::/127", "2001:db8::2"}
+
+	if got := formatPrefixes(SubtractPrefixes(a, b)); !reflect.DeepEqual(got, want) {
+		t.Errorf("SubtractPrefixes() = %v, want %v", got, want)
+	}
+}
+
+func Test_ExpandPrefixes(t *testing.T) {
+	addrs, err := ExpandPrefixes(parsePrefixes(t, "192.168.0.254/31", "::1"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, a := range addrs {
+		got = append(got, a.String())
+	}
+	want := []string{"192.168.0.254", "192.168.0.255", "::1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandPrefixes() = %v, want %v", got, want)
+	}
+
+	if _, err := ExpandPrefixes(parsePrefixes(t, "10.0.0.0/8"), 1000); err == nil {
+		t.Error("ExpandPrefixes() expected error over max")
+	}
+}
+
+func Test_ProcessIPs(t *testing.T) {
+	lines := []string{"10.0.0.10", "8.8.8.8", "1.1.1.1", "8.8.008.8", "2001:4860::8888", "192.0.2.1", "bad", "", "100.64.0.1"}
+
+	got, rejected, err := ProcessIPs(lines, IPOptions{Drop: []string{"bogon"}, Dedupe: true, Sort: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"1.1.1.1", "8.8.8.8", "2001:4860::8888"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ProcessIPs() = %v, want %v", got, want)
+	}
+	if len(rejected) != 4 {
+		t.Errorf("ProcessIPs() rejected = %v, want 4 lines", rejected)
+	}
+
+	if _, _, err := ProcessIPs(lines, IPOptions{Drop: []string{"nope"}}); err == nil {
+		t.Error("ProcessIPs() expected error for unknown class")
+	}
+}
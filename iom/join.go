@@ -0,0 +1,351 @@
+package iom
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Join modes
+const (
+	JoinInner = "inner"
+	JoinLeft  = "left"
+	JoinRight = "right"
+	JoinFull  = "full"
+	JoinAnti  = "anti"
+)
+
+// JoinOptions configures how two lists are joined
+type JoinOptions struct {
+	// Mode is one of JoinInner, JoinLeft, JoinRight, JoinFull or JoinAnti
+	Mode string
+	// LeftKey and RightKey select the key of each side
+	LeftKey  KeySelector
+	RightKey KeySelector
+	// Delim separates the left line from the right line's non-key fields in joined lines
+	Delim string
+}
+
+// JoinStats counts the lines produced by a join
+type JoinStats struct {
+	Matched   int
+	LeftOnly  int
+	RightOnly int
+	Skipped   int
+}
+
+// lineSource is a sequence of lines, such as a *LineReader
+type lineSource interface {
+	Next() (string, bool)
+}
+
+type sliceSource struct {
+	lines []string
+}
+
+func (s *sliceSource) Next() (string, bool) {
+	if len(s.lines) == 0 {
+		return "", false
+	}
+	line := s.lines[0]
+	s.lines = s.lines[1:]
+	return line, true
+}
+
+// JoinLine joins a matching left and right line: the left line followed by the right line's
+// non-key fields
+func JoinLine(left, right string, opts JoinOptions) string {
+	rest := opts.RightKey.Rest(right)
+	if len(rest) == 0 {
+		return left
+	}
+	return left + opts.Delim + strings.Join(rest, opts.Delim)
+}
+
+func validateJoin(opts JoinOptions) error {
+	switch opts.Mode {
+	case JoinInner, JoinLeft, JoinRight, JoinFull, JoinAnti:
+		return nil
+	}
+	return fmt.Errorf("unknown join mode %q", opts.Mode)
+}
+
+// HashJoin joins two lists by holding the right list in memory. Left lines keep their order and
+// unmatched right lines follow them in their own order. Unmatched lines are written unchanged
+func HashJoin(left, right []string, opts JoinOptions) ([]string, JoinStats, error) {
+	var result []string
+	stats, err := hashJoin(&sliceSource{left}, right, opts, func(line string) error {
+		result = append(result, line)
+		return nil
+	})
+	return result, stats, err
+}
+
+func hashJoin(left lineSource, right []string, opts JoinOptions, emit func(string) error) (JoinStats, error) {
+	var stats JoinStats
+	if err := validateJoin(opts); err != nil {
+		return stats, err
+	}
+
+	index := make(map[string][]int)
+	for i, line := range right {
+		k, ok := opts.RightKey.Key(line)
+		if !ok {
+			stats.Skipped++
+			continue
+		}
+		index[k] = append(index[k], i)
+	}
+
+	matched := make([]bool, len(right))
+	for line, ok := left.Next(); ok; line, ok = left.Next() {
+		k, ok := opts.LeftKey.Key(line)
+		if !ok {
+			stats.Skipped++
+			continue
+		}
+
+		ids := index[k]
+		if len(ids) == 0 {
+			if opts.Mode == JoinLeft || opts.Mode == JoinFull || opts.Mode == JoinAnti {
+				stats.LeftOnly++
+				if err := emit(line); err != nil {
+					return stats, err
+				}
+			}
+			continue
+		}
+
+		for _, i := range ids {
+			matched[i] = true
+			if opts.Mode == JoinAnti {
+				continue
+			}
+			stats.Matched++
+			if err := emit(JoinLine(line, right[i], opts)); err != nil {
+				return stats, err
+			}
+		}
+	}
+
+	if opts.Mode == JoinRight || opts.Mode == JoinFull {
+		for i, line := range right {
+			if _, ok := opts.RightKey.Key(line); !ok || matched[i] {
+				continue
+			}
+			stats.RightOnly++
+			if err := emit(line); err != nil {
+				return stats, err
+			}
+		}
+	}
+
+	return stats, nil
+}
+
+// HashJoinFiles joins two files, holding only the right file in memory and streaming the left
+func HashJoinFiles(left, right, out string, opts JoinOptions) (JoinStats, error) {
+	rightLines, err := ReadFile(right)
+	if err != nil {
+		return JoinStats{}, fmt.Errorf("hash join files: %w", err)
+	}
+
+	r, err := OpenLineReader(left)
+	if err != nil {
+		return JoinStats{}, fmt.Errorf("hash join files: %w", err)
+	}
+	defer r.Close()
+
+	w, err := CreateLineWriter(out, r.Style())
+	if err != nil {
+		return JoinStats{}, fmt.Errorf("hash join files: %w", err)
+	}
+
+	stats, err := hashJoin(r, rightLines, opts, w.Write)
+	if err == nil {
+		err = r.Err()
+	}
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return stats, fmt.Errorf("hash join files: %w", err)
+	}
+
+	return stats, nil
+}
+
+// MergeJoin joins two lists that are both sorted by key, in byte order, without an index. Lines
+// are produced in key order
+func MergeJoin(left, right []string, opts JoinOptions) ([]string, JoinStats, error) {
+	var result []string
+	stats, err := mergeJoin(&sliceSource{left}, &sliceSource{right}, opts, func(line string) error {
+		result = append(result, line)
+		return nil
+	})
+	return result, stats, err
+}
+
+// keyedRun reads consecutive lines sharing a key from a sorted lineSource
+type keyedRun struct {
+	src     lineSource
+	key     KeySelector
+	side    string
+	n       int
+	skipped int
+
+	next    string
+	nextKey string
+	done    bool
+}
+
+func newKeyedRun(src lineSource, key KeySelector, side string) (*keyedRun, error) {
+	r := &keyedRun{src: src, key: key, side: side}
+	return r, r.advance()
+}
+
+// advance reads the next line with a key, checking that keys do not decrease
+func (r *keyedRun) advance() error {
+	for {
+		line, ok := r.src.Next()
+		if !ok {
+			r.done = true
+			return nil
+		}
+		r.n++
+
+		k, ok := r.key.Key(line)
+		if !ok {
+			r.skipped++
+			continue
+		}
+		if k < r.nextKey {
+			return fmt.Errorf("%s input is not sorted by key at line %d", r.side, r.n)
+		}
+		r.next, r.nextKey = line, k
+		return nil
+	}
+}
+
+// take returns every line with the current key and moves past them
+func (r *keyedRun) take() ([]string, error) {
+	key := r.nextKey
+	var lines []string
+	for !r.done && r.nextKey == key {
+		lines = append(lines, r.next)
+		if err := r.advance(); err != nil {
+			return lines, err
+		}
+	}
+	return lines, nil
+}
+
+func mergeJoin(left, right lineSource, opts JoinOptions, emit func(string) error) (JoinStats, error) {
+	var stats JoinStats
+	if err := validateJoin(opts); err != nil {
+		return stats, err
+	}
+
+	l, err := newKeyedRun(left, opts.LeftKey, "left")
+	if err != nil {
+		return stats, err
+	}
+	r, err := newKeyedRun(right, opts.RightKey, "right")
+	if err != nil {
+		return stats, err
+	}
+
+	emitAll := func(lines []string) error {
+		for _, line := range lines {
+			if err := emit(line); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for !l.done || !r.done {
+		switch {
+		case r.done || (!l.done && l.nextKey < r.nextKey):
+			lines, err := l.take()
+			if err != nil {
+				return stats, err
+			}
+			if opts.Mode == JoinLeft || opts.Mode == JoinFull || opts.Mode == JoinAnti {
+				stats.LeftOnly += len(lines)
+				if err := emitAll(lines); err != nil {
+					return stats, err
+				}
+			}
+		case l.done || r.nextKey < l.nextKey:
+			lines, err := r.take()
+			if err != nil {
+				return stats, err
+			}
+			if opts.Mode == JoinRight || opts.Mode == JoinFull {
+				stats.RightOnly += len(lines)
+				if err := emitAll(lines); err != nil {
+					return stats, err
+				}
+			}
+		default:
+			leftLines, err := l.take()
+			if err != nil {
+				return stats, err
+			}
+			rightLines, err := r.take()
+			if err != nil {
+				return stats, err
+			}
+			if opts.Mode == JoinAnti {
+				continue
+			}
+			for _, a := range leftLines {
+				for _, b := range rightLines {
+					stats.Matched++
+					if err := emit(JoinLine(a, b, opts)); err != nil {
+						return stats, err
+					}
+				}
+			}
+		}
+	}
+
+	stats.Skipped = l.skipped + r.skipped
+	return stats, nil
+}
+
+// MergeJoinFiles joins two files that are both sorted by key, streaming both
+func MergeJoinFiles(left, right, out string, opts JoinOptions) (JoinStats, error) {
+	lr, err := OpenLineReader(left)
+	if err != nil {
+		return JoinStats{}, fmt.Errorf("merge join files: %w", err)
+	}
+	defer lr.Close()
+
+	rr, err := OpenLineReader(right)
+	if err != nil {
+		return JoinStats{}, fmt.Errorf("merge join files: %w", err)
+	}
+	defer rr.Close()
+
+	w, err := CreateLineWriter(out, lr.Style())
+	if err != nil {
+		return JoinStats{}, fmt.Errorf("merge join files: %w", err)
+	}
+
+	stats, err := mergeJoin(lr, rr, opts, w.Write)
+	if err == nil {
+		err = lr.Err()
+	}
+	if err == nil {
+		err = rr.Err()
+	}
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return stats, fmt.Errorf("merge join files: %w", err)
+	}
+
+	return stats, nil
+}
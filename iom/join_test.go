@@ -0,0 +1,83 @@
+package iom
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func Test_Join(t *testing.T) {
+	left := []string{"1", "2", "3", "3"}
+	right := []string{"1,Ann,NY", "3,Cid,LA", "4,Dee,SF"}
+
+	tests := []struct {
+		mode  string
+		want  []string
+		stats JoinStats
+	}{
+		{JoinInner, []string{"1,Ann,NY", "3,Cid,LA", "3,Cid,LA"}, JoinStats{Matched: 3}},
+		{JoinLeft, []string{"1,Ann,NY", "2", "3,Cid,LA", "3,Cid,LA"}, JoinStats{Matched: 3, LeftOnly: 1}},
+		{JoinRight, []string{"1,Ann,NY", "3,Cid,LA", "3,Cid,LA", "4,Dee,SF"}, JoinStats{Matched: 3, RightOnly: 1}},
+		{JoinFull, []string{"1,Ann,NY", "2", "3,Cid,LA", "3,Cid,LA", "4,Dee,SF"}, JoinStats{Matched: 3, LeftOnly: 1, RightOnly: 1}},
+		{JoinAnti, []string{"2"}, JoinStats{LeftOnly: 1}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.mode, func(t *testing.T) {
+			t.Parallel()
+
+			opts := JoinOptions{
+				Mode:     tt.mode,
+				RightKey: KeySelector{Delim: ",", IDs: []int{0}},
+				Delim:    ",",
+			}
+
+			got, stats, err := HashJoin(left, right, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) || stats != tt.stats {
+				t.Errorf("HashJoin() = %v %+v, want %v %+v", got, stats, tt.want, tt.stats)
+			}
+
+			got, stats, err = MergeJoin(left, right, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) || stats != tt.stats {
+				t.Errorf("MergeJoin() = %v %+v, want %v %+v", got, stats, tt.want, tt.stats)
+			}
+		})
+	}
+}
+
+func Test_MergeJoin_Unsorted(t *testing.T) {
+	_, _, err := MergeJoin([]string{"2", "1"}, []string{"1"}, JoinOptions{Mode: JoinInner})
+	if err == nil {
+		t.Error("MergeJoin() expected error for unsorted input")
+	}
+}
+
+func Test_Join_MixedDelims(t *testing.T) {
+	left := []string{"a;b;x", "a;c;y"}
+	right := []string{"a,b,1", "b,b,2"}
+	opts := JoinOptions{
+		Mode:     JoinInner,
+		LeftKey:  KeySelector{Delim: ";", IDs: []int{0, 1}},
+		RightKey: KeySelector{Delim: ",", IDs: []int{0, 1}},
+		Delim:    ";",
+	}
+
+	want := []string{"a;b;x;1"}
+	got, _, err := HashJoin(left, right, opts)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("HashJoin() = %v, %v, want %v", got, err, want)
+	}
+	got, _, err = MergeJoin(left, right, opts)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeJoin() = %v, %v, want %v", got, err, want)
+	}
+}
@@ -0,0 +1,122 @@
+package iom
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// KeySelector picks a key out of a line the same way SplitByAndPluckIDs does: the line is split
+// by Delim and the fields at IDs are joined together with KeySep, so keys of files with different
+// delimiters compare equal. A KeySelector without IDs selects the whole line
+type KeySelector struct {
+	Delim string
+	IDs   []int
+}
+
+// KeySep joins the fields of a multi-field key
+const KeySep = "\x00"
+
+// ParseKeySelector parses comma separated field ids, as given to the split command, into a
+// KeySelector. An empty ids string selects the whole line
+func ParseKeySelector(delim, ids string) (KeySelector, error) {
+	if ids == "" {
+		return KeySelector{}, nil
+	}
+	if delim == "" {
+		return KeySelector{}, fmt.Errorf("parse key selector: a delimiter is required with ids %q", ids)
+	}
+
+	var k KeySelector
+	k.Delim = delim
+	for _, v := range strings.Split(ids, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || id < 0 {
+			return KeySelector{}, fmt.Errorf("parse key selector: invalid id %q", v)
+		}
+		k.IDs = append(k.IDs, id)
+	}
+
+	return k, nil
+}
+
+// IsWholeLine reports whether the selector selects the whole line
+func (k KeySelector) IsWholeLine() bool {
+	return len(k.IDs) == 0
+}
+
+// Key returns the key of a line, or false if the line has too few fields
+func (k KeySelector) Key(line string) (string, bool) {
+	if k.IsWholeLine() {
+		return line, true
+	}
+
+	fields := strings.Split(line, k.Delim)
+	if len(k.IDs) == 1 {
+		if k.IDs[0] >= len(fields) {
+			return "", false
+		}
+		return fields[k.IDs[0]], true
+	}
+
+	plucked := make([]string, len(k.IDs))
+	for i, id := range k.IDs {
+		if id >= len(fields) {
+			return "", false
+		}
+		plucked[i] = fields[id]
+	}
+	return strings.Join(plucked, KeySep), true
+}
+
+// Format returns a key as written to output, its fields joined with Delim
+func (k KeySelector) Format(key string) string {
+	if k.IsWholeLine() {
+		return key
+	}
+	return strings.ReplaceAll(key, KeySep, k.Delim)
+}
+
+// Rest returns the fields of a line that are not selected, in order. A whole line selector has
+// no remaining fields
+func (k KeySelector) Rest(line string) []string {
+	if k.IsWholeLine() {
+		return nil
+	}
+
+	selected := make(map[int]bool, len(k.IDs))
+	for _, id := range k.IDs {
+		selected[id] = true
+	}
+
+	var rest []string
+	for i, field := range strings.Split(line, k.Delim) {
+		if !selected[i] {
+			rest = append(rest, field)
+		}
+	}
+	return rest
+}
+
+// ReadFileToKeyMap reads a file and returns the keys of its lines as a map[string]struct{}. Lines
+// without the selected fields are skipped
+func ReadFileToKeyMap(file string, key KeySelector) (map[string]struct{}, error) {
+	lines, err := ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read file to key map: %w", err)
+	}
+
+	return keyMap(lines, key), nil
+}
+
+// keyMap returns the keys of lines as a map[string]struct{}. Lines without the selected fields are
+// skipped
+func keyMap(lines []string, key KeySelector) map[string]struct{} {
+	m := make(map[string]struct{})
+	for _, line := range lines {
+		if k, ok := key.Key(line); ok {
+			m[k] = struct{}{}
+		}
+	}
+	return m
+}
@@ -0,0 +1,45 @@
+package iom
+
+import "testing"
+
+func Test_KeySelector(t *testing.T) {
+	tests := []struct {
+		name  string
+		delim string
+		ids   string
+		in    string
+		want  string
+		ok    bool
+	}{
+		{name: "whole line", in: "a,b,c", want: "a,b,c", ok: true},
+		{name: "one field", delim: ",", ids: "1", in: "a,b,c", want: "b", ok: true},
+		{name: "many fields", delim: ":", ids: "2,0", in: "a:b:c", want: "c\x00a", ok: true},
+		{name: "missing field", delim: ",", ids: "3", in: "a,b,c", ok: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			k, err := ParseKeySelector(tt.delim, tt.ids)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, ok := k.Key(tt.in)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("Key() = %v, %v, want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func Test_ParseKeySelector_Invalid(t *testing.T) {
+	if _, err := ParseKeySelector(",", "a"); err == nil {
+		t.Error("ParseKeySelector() expected error for non numeric id")
+	}
+	if _, err := ParseKeySelector("", "1"); err == nil {
+		t.Error("ParseKeySelector() expected error for missing delimiter")
+	}
+}
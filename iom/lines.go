@@ -0,0 +1,459 @@
+package iom
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// FileOptions configure how every LineReader and LineWriter reads and writes files
+type FileOptions struct {
+	// Encrypt encrypts written files with Encryption
+	Encrypt bool
+	// Encryption decrypts encrypted input files and encrypts output files if Encrypt is set
+	Encryption *Encryption
+	// InputEncoding is the encoding of input files, converted to UTF-8. Empty or EncodingAuto
+	// detects it
+	InputEncoding string
+	// OutputEncoding is the encoding of output files, UTF-8 if empty
+	OutputEncoding string
+	// EOL is the line ending of output files: EOLLF, EOLCRLF or, if empty, EOLPreserve to use
+	// the line ending of the input file they are written from
+	EOL string
+	// FinalNewline controls whether the last line of output files ends with a newline:
+	// FinalNewlineAlways, FinalNewlineNever or, if empty, FinalNewlinePreserve to follow the
+	// input file they are written from
+	FinalNewline string
+	// MaxLineLength is the longest line in bytes a LineReader accepts, 0 for unlimited
+	MaxLineLength int
+	// RecordSep separates the records read as lines: RecordSepNewline if empty, RecordSepNUL,
+	// RecordSepParagraph for blocks of lines separated by blank lines, a regex after
+	// RecordSepRegexPrefix or a literal, which may use Go escapes such as \t
+	RecordSep string
+	// OutputRecordSep separates written records, RecordSep if empty, or newlines if RecordSep is
+	// a regex
+	OutputRecordSep string
+}
+
+// ErrLineTooLong is returned by LineReader for lines longer than FileOptions.MaxLineLength
+var ErrLineTooLong = errors.New("line too long")
+
+var fileOptions FileOptions
+
+// SetFileOptions sets the options used by every LineReader and LineWriter
+func SetFileOptions(opts FileOptions) error {
+	if opts.Encrypt && opts.Encryption == nil {
+		return fmt.Errorf("set file options: encrypting requires a passphrase or keyfile")
+	}
+	if err := ValidateEncoding(opts.InputEncoding); opts.InputEncoding != "" && err != nil {
+		return fmt.Errorf("set file options: input %w", err)
+	}
+	if err := ValidateEncoding(opts.OutputEncoding); opts.OutputEncoding != "" && (err != nil || opts.OutputEncoding == EncodingAuto) {
+		return fmt.Errorf("set file options: invalid output encoding %q", opts.OutputEncoding)
+	}
+	if err := validateLineStyle(opts.EOL, opts.FinalNewline); err != nil {
+		return fmt.Errorf("set file options: %w", err)
+	}
+	if opts.MaxLineLength < 0 {
+		return fmt.Errorf("set file options: negative max line length %d", opts.MaxLineLength)
+	}
+	if err := setRecordSeps(opts.RecordSep, opts.OutputRecordSep); err != nil {
+		return fmt.Errorf("set file options: %w", err)
+	}
+	fileOptions = opts
+	return nil
+}
+
+// GetFileOptions returns the options used by every LineReader and LineWriter
+func GetFileOptions() FileOptions {
+	return fileOptions
+}
+
+// LineReader reads a file one line at a time, for inputs too large to hold in memory. Lines end
+// with \n or \r\n, which are both dropped, and may be of any length up to
+// FileOptions.MaxLineLength. With FileOptions.RecordSep, each line is a record instead
+type LineReader struct {
+	f        *os.File
+	file     string
+	r        *bufio.Reader
+	sep      *recordSep
+	splitter *lineSplitter
+	max      int
+	line     int
+	err      error
+	done     bool
+	// style is detected from the start of the file when it is opened, so lines written while
+	// reading it follow it too, and updated once the whole file is read
+	style *LineStyle
+
+	// unread input of regex separated records, read a chunk at a time, of which the bytes
+	// before searched hold no separator
+	pending  []byte
+	chunk    []byte
+	searched int
+	eof      bool
+}
+
+// OpenLineReader opens a file for reading line by line. Encrypted files are decrypted and lines
+// are converted to UTF-8 from FileOptions.InputEncoding
+func OpenLineReader(file string) (*LineReader, error) {
+	return openLineReader(file, readSep, fileOptions.MaxLineLength)
+}
+
+// openLineReader opens a file for reading records separated by sep of up to max bytes
+func openLineReader(file string, sep *recordSep, max int) (*LineReader, error) {
+	raw, f, err := openRaw(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading file: %w", err)
+	}
+
+	r, err := decodeInput(raw, fileOptions.InputEncoding, sep)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("reading file: %s: %w", file, err)
+	}
+
+	lr := newLineReader(file, f, r)
+	lr.sep, lr.max = sep, max
+	return lr, nil
+}
+
+func newLineReader(file string, f *os.File, r io.Reader) *LineReader {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	lr := &LineReader{f: f, file: file, r: br, sep: readSep, splitter: &lineSplitter{}, max: fileOptions.MaxLineLength}
+	if style, ok := peekStyle(br); ok {
+		lr.style = &style
+	}
+	return lr
+}
+
+// openRaw opens a file and returns a reader of its bytes, decrypted if it is encrypted
+func openRaw(file string) (*bufio.Reader, *os.File, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, err := decryptInput(file, f)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return r, f, nil
+}
+
+// Next returns the next line, or false at the end of the file or on error
+func (r *LineReader) Next() (string, bool) {
+	b, ok := r.next()
+	return string(b), ok
+}
+
+// next returns the next line as bytes, which are only valid until the next call
+func (r *LineReader) next() ([]byte, bool) {
+	if r.done {
+		return nil, false
+	}
+
+	var line []byte
+	var ok bool
+	var err error
+	switch r.sep.kind {
+	case sepParagraph:
+		line, ok, err = r.readParagraph()
+	case sepLiteral:
+		line, ok, err = r.readUntil(r.sep.literal)
+	case sepRegex:
+		line, ok, err = r.readRegex(r.sep.re)
+	default:
+		line, ok, err = r.readLine()
+	}
+
+	if err == nil && ok && r.max > 0 && len(line) > r.max {
+		err = r.tooLong()
+	}
+	if err != nil {
+		return nil, r.fail(err)
+	}
+	if !ok {
+		r.done = true
+		if style, ok := r.splitter.style(); ok {
+			if r.style == nil {
+				r.style = &style
+			} else {
+				*r.style = style
+			}
+		}
+		return nil, false
+	}
+
+	r.line++
+	return line, true
+}
+
+// readLine reads up to the next \n
+func (r *LineReader) readLine() ([]byte, bool, error) {
+	var buf []byte
+	for {
+		chunk, err := r.r.ReadSlice('\n')
+		if buf == nil && err != bufio.ErrBufferFull {
+			// the whole line is in the reader's buffer
+			buf = chunk
+		} else {
+			buf = append(buf, chunk...)
+		}
+		// allow for the \r\n ending the line
+		if r.max > 0 && len(buf) > r.max+2 {
+			return nil, false, r.tooLong()
+		}
+
+		switch {
+		case err == bufio.ErrBufferFull:
+			continue
+		case err == io.EOF && len(buf) == 0:
+			return nil, false, nil
+		case err != nil && err != io.EOF:
+			return nil, false, err
+		}
+
+		return r.splitter.trim(buf, err == nil), true, nil
+	}
+}
+
+func (r *LineReader) fail(err error) bool {
+	r.err = err
+	r.done = true
+	return false
+}
+
+// Style returns the line style of the file, for writers preserving it, or nil for an empty file.
+// It is exact once the whole file is read
+func (r *LineReader) Style() *LineStyle {
+	return r.style
+}
+
+// Line returns the number of the line last returned by Next, starting at 1
+func (r *LineReader) Line() int {
+	return r.line
+}
+
+// Err returns the first error encountered while reading
+func (r *LineReader) Err() error {
+	return r.err
+}
+
+// Close closes the underlying file
+func (r *LineReader) Close() error {
+	return r.f.Close()
+}
+
+// LineWriter writes lines to a file through a buffer, converting them to FileOptions.OutputEncoding
+// and encrypting them if FileOptions.Encrypt is set
+type LineWriter struct {
+	f       *os.File
+	out     *bufio.Writer
+	enc     *encryptWriter
+	w       *bufio.Writer
+	encoder *lineEncoder
+	// in is the style of the input, preserved unless FileOptions override it
+	in    *LineStyle
+	style LineStyle
+	// sep is written between lines and term after the last one
+	sep, term string
+	// paragraph is set when lines are blocks of lines, whose newlines follow the output style
+	paragraph bool
+	line      int
+	// pending is set when the last written line still needs its newline
+	pending bool
+}
+
+// CreateLineWriter creates or truncates a file for writing line by line. Its lines follow the
+// style of the input they come from, nil for none, unless FileOptions override it
+func CreateLineWriter(file string, in *LineStyle) (*LineWriter, error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return nil, fmt.Errorf("write file: %w", err)
+	}
+
+	w, err := newLineWriter(f, in)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("write file: %w", err)
+	}
+	return w, nil
+}
+
+// AppendLineWriter opens a file for appending line by line, creating it if it does not exist, like
+// CreateLineWriter. Encrypted lines are appended as a new encrypted stream, and cannot be mixed
+// with plaintext. Each stream is authenticated on its own, not the file as a whole, and adds a
+// header
+func AppendLineWriter(file string, in *LineStyle) (*LineWriter, error) {
+	if err := checkAppendable(file); err != nil {
+		return nil, fmt.Errorf("append file: %w", err)
+	}
+
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("append file: %w", err)
+	}
+
+	w, err := newLineWriter(f, in)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("append file: %w", err)
+	}
+	w.pending, err = missingTerminator(file, w.encoder, w.style)
+	if err == nil && w.paragraph && !w.pending {
+		// a blank line separates appended blocks from the last one
+		if info, _ := f.Stat(); info != nil && info.Size() > 0 {
+			err = w.writeString(w.style.newline())
+		}
+	}
+	if err != nil {
+		w.Close()
+		return nil, fmt.Errorf("append file: %w", err)
+	}
+	return w, nil
+}
+
+// checkAppendable checks an existing file is encrypted if and only if written files are
+func checkAppendable(file string) error {
+	info, err := os.Stat(file)
+	if err != nil || info.Size() == 0 {
+		return nil
+	}
+
+	encrypted, err := IsEncryptedFile(file)
+	if err != nil {
+		return err
+	}
+	if encrypted && !fileOptions.Encrypt {
+		return fmt.Errorf("cannot append plaintext lines to encrypted %s", file)
+	}
+	if !encrypted && fileOptions.Encrypt {
+		return fmt.Errorf("cannot append encrypted lines to plaintext %s", file)
+	}
+	return nil
+}
+
+// missingTerminator reports whether a non-empty file does not end with the terminator of written
+// records, a newline unless FileOptions.OutputRecordSep is set, so appended lines must start on a
+// new record. Encrypted files are assumed to follow the output style
+func missingTerminator(file string, encoder *lineEncoder, style LineStyle) (bool, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return false, err
+	}
+	if encrypted, err := IsEncryptedFile(file); err != nil || encrypted {
+		return !style.finalNewline, err
+	}
+
+	term := []byte(writeSep.terminator())
+	if encoder != nil {
+		if term, err = encoder.enc.Bytes(term); err != nil {
+			return false, err
+		}
+	}
+	if info.Size() < int64(len(term)) {
+		return true, nil
+	}
+
+	last := make([]byte, len(term))
+	if _, err := f.ReadAt(last, info.Size()-int64(len(last))); err != nil {
+		return false, err
+	}
+	return !bytes.Equal(last, term), nil
+}
+
+func newLineWriter(f *os.File, in *LineStyle) (*LineWriter, error) {
+	encoder, err := newLineEncoder(fileOptions.OutputEncoding)
+	if err != nil {
+		return nil, err
+	}
+
+	lw := &LineWriter{f: f, out: bufio.NewWriter(f), encoder: encoder, in: in, style: outputStyle(in)}
+	lw.sep, lw.term = writeSep.separators(lw.style)
+	lw.paragraph = writeSep.kind == sepParagraph
+	var w io.Writer = lw.out
+	if fileOptions.Encrypt {
+		enc, err := newEncryptWriter(lw.out, fileOptions.Encryption)
+		if err != nil {
+			return nil, err
+		}
+		lw.enc = enc
+		w = enc
+	}
+	lw.w = bufio.NewWriter(w)
+
+	// a byte order mark only starts the file, not appended lines
+	if info, err := f.Stat(); err == nil && info.Size() == 0 && encoder != nil {
+		lw.w.Write(encoder.bom)
+	}
+	return lw, nil
+}
+
+// Write writes a line. Its newline, or record separator, is written before the next line, or by
+// Close
+func (w *LineWriter) Write(line string) error {
+	w.line++
+	if w.pending {
+		if err := w.writeString(w.sep); err != nil {
+			return err
+		}
+	}
+	w.pending = true
+	if w.paragraph && w.style.newline() != "\n" {
+		line = strings.ReplaceAll(line, "\n", w.style.newline())
+	}
+	return w.writeString(line)
+}
+
+func (w *LineWriter) writeString(s string) error {
+	if w.encoder != nil {
+		b, err := w.encoder.enc.Bytes([]byte(s))
+		if err != nil {
+			return fmt.Errorf("encode line %d: %w", w.line, err)
+		}
+		_, err = w.w.Write(b)
+		return err
+	}
+
+	_, err := w.w.WriteString(s)
+	return err
+}
+
+// Close ends the last line with a newline unless the final newline is disabled, flushes buffered
+// lines and closes the underlying file. A preserved final newline follows the input as read so
+// far, as its end may not have been read when the writer was created
+func (w *LineWriter) Close() error {
+	var err error
+	if w.pending && w.line > 0 && outputStyle(w.in).finalNewline {
+		err = w.writeString(w.term)
+	}
+	if err == nil {
+		err = w.w.Flush()
+	}
+	if err == nil && w.enc != nil {
+		err = w.enc.Close()
+	}
+	if err == nil {
+		err = w.out.Flush()
+	}
+	if err != nil {
+		w.f.Close()
+		return err
+	}
+	return w.f.Close()
+}
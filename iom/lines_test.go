@@ -0,0 +1,44 @@
+package iom
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_LineReaderLongLines(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "long.txt")
+	long := strings.Repeat("x", 1<<20)
+	if err := os.WriteFile(file, []byte("short\r\n"+long+"\r\nend"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadFile(file)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if len(got) != 3 || got[0] != "short" || got[1] != long || got[2] != "end" {
+		t.Errorf("ReadFile() read %d lines", len(got))
+	}
+
+	setTestFileOptions(t, FileOptions{MaxLineLength: 1000})
+	_, err = ReadFile(file)
+	if !errors.Is(err, ErrLineTooLong) || !strings.Contains(err.Error(), file+":2:") {
+		t.Errorf("ReadFile() error = %v, want %v at %s:2", err, ErrLineTooLong, file)
+	}
+
+	// the limit excludes the line ending
+	SetFileOptions(FileOptions{MaxLineLength: 5})
+	if err := os.WriteFile(file, []byte("12345\r\n12345"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadFile(file); err != nil {
+		t.Errorf("ReadFile() error = %v for lines at the limit", err)
+	}
+
+	if err := SetFileOptions(FileOptions{MaxLineLength: -1}); err == nil {
+		t.Error("SetFileOptions() expected error for a negative max line length")
+	}
+}
@@ -0,0 +1,240 @@
+package iom
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Mask actions accepted by ParseMaskRule
+const (
+	MaskEmailAction = "email"
+	MaskPhoneAction = "phone"
+	MaskRedact      = "redact"
+	MaskTokenize    = "tokenize"
+	MaskDrop        = "drop"
+)
+
+// MaskActions returns the names of the mask actions
+func MaskActions() []string {
+	return []string{MaskEmailAction, MaskPhoneAction, MaskRedact, MaskTokenize, MaskDrop}
+}
+
+// RedactedValue replaces redacted values
+const RedactedValue = "***"
+
+// MaskRule applies an action to fields of a line, or to the matches of a regex in unstructured
+// lines. A rule without fields or a regex applies email and phone to every email address or phone
+// number found in the line, and redact and tokenize to the whole line
+type MaskRule struct {
+	Action string
+	Fields []int
+	Match  *regexp.Regexp
+}
+
+// ParseMaskRule parses "<action>[:<target>]" where the target is comma separated field ids, e.g.
+// "email:2" or "drop:3,4", or a regex after a ~, e.g. "redact:~\d{3}-\d{2}-\d{4}"
+func ParseMaskRule(s string) (MaskRule, error) {
+	action, target, _ := strings.Cut(s, ":")
+
+	var r MaskRule
+	switch action {
+	case MaskEmailAction, MaskPhoneAction, MaskRedact, MaskTokenize, MaskDrop:
+		r.Action = action
+	default:
+		return MaskRule{}, fmt.Errorf("parse mask rule: unknown action %q, expected one of %s", action, strings.Join(MaskActions(), ", "))
+	}
+
+	switch {
+	case strings.HasPrefix(target, "~"):
+		re, err := regexp.Compile(target[1:])
+		if err != nil {
+			return MaskRule{}, fmt.Errorf("parse mask rule: %w", err)
+		}
+		r.Match = re
+	case target != "":
+		for _, v := range strings.Split(target, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil || id < 0 {
+				return MaskRule{}, fmt.Errorf("parse mask rule: invalid field id %q", v)
+			}
+			r.Fields = append(r.Fields, id)
+		}
+	}
+
+	if r.Action == MaskDrop && len(r.Fields) == 0 {
+		return MaskRule{}, errors.New("parse mask rule: drop requires field ids")
+	}
+	return r, nil
+}
+
+// MaskEmail keeps the first character of the local part and the domain, e.g. j***@example.com.
+// Values without an @ are redacted
+func MaskEmail(s string) string {
+	at := strings.LastIndexByte(s, '@')
+	if at <= 0 {
+		return RedactedValue
+	}
+	_, size := utf8.DecodeRuneInString(s)
+	return s[:size] + RedactedValue + s[at:]
+}
+
+// MaskPhone replaces every digit except the last four with *, keeping the formatting, e.g.
+// +1 415-555-2671 becomes +* ***-***-2671
+func MaskPhone(s string) string {
+	digits := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+
+	b := []byte(s)
+	for i := range b {
+		if b[i] >= '0' && b[i] <= '9' {
+			if digits > 4 {
+				b[i] = '*'
+			}
+			digits--
+		}
+	}
+	return string(b)
+}
+
+// Masker applies mask rules to lines
+type Masker struct {
+	Rules []MaskRule
+	// Delim splits lines into the fields selected by rules
+	Delim string
+
+	tokenizer *Hasher
+	// extractors find the values of email and phone rules without fields or a regex
+	extractors map[string]Extractor
+}
+
+// NewMasker returns a Masker. Tokenize rules require a secret, which keys the pseudonyms so the
+// same value always gets the same token and tokenized lists can still be joined
+func NewMasker(rules []MaskRule, delim string, secret []byte) (*Masker, error) {
+	m := &Masker{Rules: rules, Delim: delim, extractors: make(map[string]Extractor)}
+	for _, r := range rules {
+		if len(r.Fields) > 0 && delim == "" {
+			return nil, errors.New("new masker: a delimiter is required with field ids")
+		}
+		if (r.Action == MaskEmailAction || r.Action == MaskPhoneAction) && len(r.Fields) == 0 && r.Match == nil {
+			e, err := BuiltinExtractor(r.Action)
+			if err != nil {
+				return nil, fmt.Errorf("new masker: %w", err)
+			}
+			m.extractors[r.Action] = e
+		}
+		if r.Action == MaskTokenize && m.tokenizer == nil {
+			h, err := NewHasher(HashHMACSHA256, secret, nil)
+			if err != nil {
+				return nil, errors.New("new masker: tokenize requires a secret")
+			}
+			m.tokenizer = h
+		}
+	}
+	return m, nil
+}
+
+// Tokenize returns the keyed pseudonym of a value
+func (m *Masker) Tokenize(s string) string {
+	return "tok_" + m.tokenizer.Hash(s)[:16]
+}
+
+func (m *Masker) apply(action, s string) string {
+	switch action {
+	case MaskEmailAction:
+		return MaskEmail(s)
+	case MaskPhoneAction:
+		return MaskPhone(s)
+	case MaskTokenize:
+		return m.Tokenize(s)
+	}
+	return RedactedValue
+}
+
+// Mask applies the rules to a line in order. Fields are selected by their position in the
+// original line and dropped fields are removed last
+func (m *Masker) Mask(line string) string {
+	var fields []string
+	dropped := make(map[int]bool)
+	for _, r := range m.Rules {
+		if len(r.Fields) == 0 {
+			continue
+		}
+		if fields == nil {
+			fields = strings.Split(line, m.Delim)
+		}
+		for _, id := range r.Fields {
+			if id >= len(fields) {
+				continue
+			}
+			if r.Action == MaskDrop {
+				dropped[id] = true
+				continue
+			}
+			fields[id] = m.apply(r.Action, fields[id])
+		}
+	}
+
+	if fields != nil {
+		kept := fields[:0]
+		for i, f := range fields {
+			if !dropped[i] {
+				kept = append(kept, f)
+			}
+		}
+		line = strings.Join(kept, m.Delim)
+	}
+
+	for _, r := range m.Rules {
+		switch {
+		case len(r.Fields) > 0:
+		case r.Match != nil:
+			line = r.Match.ReplaceAllStringFunc(line, func(s string) string { return m.apply(r.Action, s) })
+		case r.Action == MaskEmailAction || r.Action == MaskPhoneAction:
+			line = m.extractors[r.Action].ReplaceAll(line, func(s string) string { return m.apply(r.Action, s) })
+		default:
+			line = m.apply(r.Action, line)
+		}
+	}
+	return line
+}
+
+// MaskLines masks every line
+func MaskLines(lines []string, m *Masker) []string {
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		result[i] = m.Mask(line)
+	}
+	return result
+}
+
+// MaskFile masks the lines of src into dst. It returns the number of changed lines
+func MaskFile(src, dst string, m *Masker) (int, error) {
+	lines, style, err := ReadFileStyle(src)
+	if err != nil {
+		return 0, fmt.Errorf("mask file: %w", err)
+	}
+
+	result := MaskLines(lines, m)
+
+	var n int
+	for i := range lines {
+		if lines[i] != result[i] {
+			n++
+		}
+	}
+
+	err = WriteFileStyle(dst, result, style)
+	if err != nil {
+		return 0, fmt.Errorf("mask file: %w", err)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,84 @@
+package iom
+
+import (
+	"testing"
+)
+
+func Test_MaskEmailPhone(t *testing.T) {
+	emails := map[string]string{
+		"john.doe@example.com": "j***@example.com",
+		"élise@example.fr":     "é***@example.fr",
+		"nope":                 RedactedValue,
+	}
+	for in, want := range emails {
+		if got := MaskEmail(in); got != want {
+			t.Errorf("MaskEmail(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	if got := MaskPhone("+1 415-555-2671"); got != "+* ***-***-2671" {
+		t.Errorf("MaskPhone() = %q", got)
+	}
+}
+
+func Test_ParseMaskRule(t *testing.T) {
+	r, err := ParseMaskRule("drop:3,4")
+	if err != nil || r.Action != MaskDrop || len(r.Fields) != 2 {
+		t.Errorf("ParseMaskRule(drop:3,4) = %+v, %v", r, err)
+	}
+
+	r, err = ParseMaskRule(`redact:~\d{3}-\d{2}-\d{4}`)
+	if err != nil || r.Match == nil {
+		t.Errorf("ParseMaskRule(redact:~...) = %+v, %v", r, err)
+	}
+
+	for _, in := range []string{"blur:1", "drop", "email:x", "redact:~("} {
+		if _, err := ParseMaskRule(in); err == nil {
+			t.Errorf("ParseMaskRule(%q) expected error", in)
+		}
+	}
+}
+
+func Test_Masker(t *testing.T) {
+	var rules []MaskRule
+	for _, v := range []string{"email:1", "tokenize:0", "drop:3", "redact:2", `redact:~\d{3}-\d{2}-\d{4}`} {
+		r, err := ParseMaskRule(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules = append(rules, r)
+	}
+
+	m, err := NewMasker(rules, ",", []byte("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := m.Mask("bob,bob@example.com,secret,dropme,ssn 123-45-6789")
+	want := m.Tokenize("bob") + ",b***@example.com,***,ssn ***"
+	if got != want {
+		t.Errorf("Mask() = %q, want %q", got, want)
+	}
+	if m.Tokenize("bob") != m.Tokenize("bob") || m.Tokenize("bob") == m.Tokenize("alice") {
+		t.Error("Tokenize() is not deterministic per value")
+	}
+
+	if _, err := NewMasker(rules, ",", nil); err == nil {
+		t.Error("NewMasker() expected error for tokenize without a secret")
+	}
+}
+
+func Test_Masker_Unstructured(t *testing.T) {
+	email, _ := ParseMaskRule("email")
+	phone, _ := ParseMaskRule("phone")
+	m, err := NewMasker([]MaskRule{email, phone}, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := m.Mask("call jane@example.com or +1 415-555-2671 today")
+	want := "call j***@example.com or +* ***-***-2671 today"
+	if got != want {
+		t.Errorf("Mask() = %q, want %q", got, want)
+	}
+}
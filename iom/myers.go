@@ -0,0 +1,405 @@
+package iom
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Edit operations of an ordered line diff
+const (
+	EditEqual  = ' '
+	EditDelete = '-'
+	EditInsert = '+'
+)
+
+// Edit is a single line of an ordered diff
+type Edit struct {
+	Op   byte
+	Line string
+}
+
+// MyersDiff returns the shortest edit script turning a into b using Myers' O(ND) algorithm in
+// linear space. Within a change, deletions come before insertions
+func MyersDiff(a, b []string) []Edit {
+	ids := make(map[string]int)
+	intern := func(s []string) []int {
+		r := make([]int, len(s))
+		for i, v := range s {
+			id, ok := ids[v]
+			if !ok {
+				id = len(ids)
+				ids[v] = id
+			}
+			r[i] = id
+		}
+		return r
+	}
+
+	d := &myers{
+		a:   intern(a),
+		b:   intern(b),
+		del: make([]bool, len(a)),
+		ins: make([]bool, len(b)),
+	}
+	d.compare(0, len(a), 0, len(b))
+
+	var edits []Edit
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && d.del[i]:
+			edits = append(edits, Edit{EditDelete, a[i]})
+			i++
+		case j < len(b) && d.ins[j]:
+			edits = append(edits, Edit{EditInsert, b[j]})
+			j++
+		default:
+			edits = append(edits, Edit{EditEqual, a[i]})
+			i++
+			j++
+		}
+	}
+
+	return edits
+}
+
+type myers struct {
+	a, b     []int
+	del, ins []bool
+}
+
+// compare marks the lines of a[aLo:aHi] deleted and b[bLo:bHi] inserted by a shortest edit script
+func (d *myers) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		aLo++
+		bLo++
+	}
+	for aLo < aHi && bLo < bHi && d.a[aHi-1] == d.b[bHi-1] {
+		aHi--
+		bHi--
+	}
+
+	switch {
+	case aLo == aHi:
+		for j := bLo; j < bHi; j++ {
+			d.ins[j] = true
+		}
+	case bLo == bHi:
+		for i := aLo; i < aHi; i++ {
+			d.del[i] = true
+		}
+	default:
+		x, y := d.split(aLo, aHi, bLo, bHi)
+		d.compare(aLo, x, bLo, y)
+		d.compare(x, aHi, y, bHi)
+	}
+}
+
+// split returns a point on a shortest edit path through a[aLo:aHi] and b[bLo:bHi] by searching
+// forwards and backwards until the paths overlap. Both ranges must be non-empty and must differ in
+// their first and last lines, which guarantees the point is neither corner
+func (d *myers) split(aLo, aHi, bLo, bHi int) (int, int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	max := (n + m + 1) / 2
+	off := max + 1
+
+	// vf[off+k] and vb[off+k] hold the furthest x reached on diagonal k, or -1 if unreachable.
+	// vb works on the reversed ranges
+	vf := make([]int, 2*off+1)
+	vb := make([]int, 2*off+1)
+	for i := range vf {
+		vf[i], vb[i] = -1, -1
+	}
+
+	next := func(v []int, k, hi, lo int) int {
+		x := -1
+		if down := v[off+k+1]; down >= 0 && down-k <= lo {
+			x = down
+		}
+		if right := v[off+k-1]; right >= 0 && right+1 <= hi && right+1 > x {
+			x = right + 1
+		}
+		return x
+	}
+
+	for D := 0; D <= max; D++ {
+		for k := -D; k <= D; k += 2 {
+			x := 0
+			if D > 0 {
+				x = next(vf, k, n, m)
+			}
+			if x < 0 {
+				vf[off+k] = -1
+				continue
+			}
+
+			sx := x
+			for y := x - k; x < n && y < m && d.a[aLo+x] == d.b[bLo+y]; y++ {
+				x++
+			}
+			vf[off+k] = x
+
+			if r := delta - k; delta%2 != 0 && r >= -(D-1) && r <= D-1 && vb[off+r] >= 0 && x+vb[off+r] >= n {
+				return aLo + sx, bLo + sx - k
+			}
+		}
+
+		for k := -D; k <= D; k += 2 {
+			x := 0
+			if D > 0 {
+				x = next(vb, k, n, m)
+			}
+			if x < 0 {
+				vb[off+k] = -1
+				continue
+			}
+
+			sx := x
+			for y := x - k; x < n && y < m && d.a[aHi-1-x] == d.b[bHi-1-y]; y++ {
+				x++
+			}
+			vb[off+k] = x
+
+			if f := delta - k; delta%2 == 0 && f >= -D && f <= D && vf[off+f] >= 0 && x+vf[off+f] >= n {
+				return aHi - sx, bHi - (sx - k)
+			}
+		}
+	}
+
+	// unreachable for non-empty ranges, fall back to replacing everything
+	return aHi, bLo
+}
+
+// UnifiedDiff formats an edit script as a unified diff with the given number of context lines.
+// It returns nil if there are no changes
+func UnifiedDiff(oldName, newName string, edits []Edit, context int) []string {
+	var result []string
+
+	i := 0
+	oldLine, newLine := 1, 1
+	for i < len(edits) {
+		if edits[i].Op == EditEqual {
+			i++
+			oldLine++
+			newLine++
+			continue
+		}
+
+		// start the hunk up to context lines before the first change
+		start := i
+		for start > 0 && i-start < context && edits[start-1].Op == EditEqual {
+			start--
+		}
+		hunkOld, hunkNew := oldLine-(i-start), newLine-(i-start)
+
+		// extend the hunk until a run of equal lines is longer than twice the context
+		end := i
+		for end < len(edits) {
+			if edits[end].Op != EditEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(edits) && edits[run].Op == EditEqual {
+				run++
+			}
+			if run == len(edits) || run-end > 2*context {
+				end += minInt(run-end, context)
+				break
+			}
+			end = run
+		}
+
+		var body []string
+		var oldCount, newCount int
+		for _, e := range edits[start:end] {
+			body = append(body, string(e.Op)+e.Line)
+			if e.Op != EditInsert {
+				oldCount++
+			}
+			if e.Op != EditDelete {
+				newCount++
+			}
+		}
+
+		if result == nil {
+			result = append(result, "--- "+oldName, "+++ "+newName)
+		}
+		result = append(result, "@@ -"+hunkRange(hunkOld, oldCount)+" +"+hunkRange(hunkNew, newCount)+" @@")
+		result = append(result, body...)
+
+		for _, e := range edits[i:end] {
+			if e.Op != EditInsert {
+				oldLine++
+			}
+			if e.Op != EditDelete {
+				newLine++
+			}
+		}
+		i = end
+	}
+
+	return result
+}
+
+// hunkRange formats the start and length of a hunk. An empty range starts at the line before it
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	return strconv.Itoa(start) + "," + strconv.Itoa(count)
+}
+
+// UnifiedDiffFiles writes the unified diff between two files to out and returns the number of
+// changed lines
+func UnifiedDiffFiles(src1, src2, out string, context int) (int, error) {
+	a, err := ReadFile(src1)
+	if err != nil {
+		return 0, fmt.Errorf("unified diff files: %w", err)
+	}
+
+	b, style, err := ReadFileStyle(src2)
+	if err != nil {
+		return 0, fmt.Errorf("unified diff files: %w", err)
+	}
+
+	edits := MyersDiff(a, b)
+	n := 0
+	for _, e := range edits {
+		if e.Op != EditEqual {
+			n++
+		}
+	}
+
+	err = WriteFileStyle(out, UnifiedDiff(src1, src2, edits, context), style)
+	if err != nil {
+		return 0, fmt.Errorf("unified diff files: %w", err)
+	}
+
+	return n, nil
+}
+
+// ApplyUnifiedDiff applies a unified diff to lines. Context and removed lines must match exactly
+func ApplyUnifiedDiff(lines []string, patch []string) ([]string, error) {
+	var result []string
+	pos := 0
+
+	for i := 0; i < len(patch); i++ {
+		p := patch[i]
+		if !strings.HasPrefix(p, "@@ ") {
+			continue
+		}
+
+		oldStart, oldCount, newCount, err := parseHunkHeader(p)
+		if err != nil {
+			return nil, fmt.Errorf("apply unified diff: line %d: %w", i+1, err)
+		}
+
+		// an empty old range starts after oldStart, otherwise at it
+		at := oldStart - 1
+		if oldCount == 0 {
+			at = oldStart
+		}
+		if at < pos || at > len(lines) {
+			return nil, fmt.Errorf("apply unified diff: line %d: hunk out of range", i+1)
+		}
+		result = append(result, lines[pos:at]...)
+		pos = at
+
+		seenOld, seenNew := 0, 0
+		for seenOld < oldCount || seenNew < newCount {
+			i++
+			if i >= len(patch) {
+				return nil, fmt.Errorf("apply unified diff: unexpected end of hunk")
+			}
+			p := patch[i]
+			if strings.HasPrefix(p, "\\") {
+				continue
+			}
+			if p == "" {
+				p = " "
+			}
+
+			op, line := p[0], p[1:]
+			switch op {
+			case EditEqual, EditDelete:
+				if pos >= len(lines) || lines[pos] != line {
+					return nil, fmt.Errorf("apply unified diff: line %d: does not match line %d of input", i+1, pos+1)
+				}
+				if op == EditEqual {
+					result = append(result, line)
+					seenNew++
+				}
+				pos++
+				seenOld++
+			case EditInsert:
+				result = append(result, line)
+				seenNew++
+			default:
+				return nil, fmt.Errorf("apply unified diff: line %d: unexpected %q", i+1, p)
+			}
+		}
+		if seenOld != oldCount || seenNew != newCount {
+			return nil, fmt.Errorf("apply unified diff: line %d: hunk length mismatch", i+1)
+		}
+	}
+
+	return append(result, lines[pos:]...), nil
+}
+
+// parseHunkHeader parses a "@@ -l,s +l,s @@" header, where the lengths default to 1
+func parseHunkHeader(h string) (int, int, int, error) {
+	fields := strings.Fields(h)
+	if len(fields) < 4 || fields[3] != "@@" || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return 0, 0, 0, fmt.Errorf("invalid hunk header %q", h)
+	}
+
+	parse := func(r string) (int, int, error) {
+		start, count := r, "1"
+		if i := strings.IndexByte(r, ','); i >= 0 {
+			start, count = r[:i], r[i+1:]
+		}
+		s, err := strconv.Atoi(start)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid hunk header %q", h)
+		}
+		c, err := strconv.Atoi(count)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid hunk header %q", h)
+		}
+		return s, c, nil
+	}
+
+	oldStart, oldCount, err := parse(fields[1][1:])
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	_, newCount, err := parse(fields[2][1:])
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	return oldStart, oldCount, newCount, nil
+}
+
+// PatchFile applies the unified diff in patchFile to src and writes the result to dst
+func PatchFile(src, patchFile, dst string) error {
+	lines, style, err := ReadFileStyle(src)
+	if err != nil {
+		return fmt.Errorf("patch file: %w", err)
+	}
+
+	patch, err := ReadFile(patchFile)
+	if err != nil {
+		return fmt.Errorf("patch file: %w", err)
+	}
+
+	result, err := ApplyUnifiedDiff(lines, patch)
+	if err != nil {
+		return fmt.Errorf("patch file: %w", err)
+	}
+
+	return WriteFileStyle(dst, result, style)
+}
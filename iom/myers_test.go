@@ -0,0 +1,123 @@
+package iom
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func Test_MyersDiff(t *testing.T) {
+	a := []string{"a", "b", "c", "a", "b", "b", "a"}
+	b := []string{"c", "b", "a", "b", "a", "c"}
+
+	edits := MyersDiff(a, b)
+	changes := 0
+	for _, e := range edits {
+		if e.Op != EditEqual {
+			changes++
+		}
+	}
+
+	// the example from Myers' paper has an edit distance of 5
+	if changes != 5 {
+		t.Errorf("MyersDiff() = %d changes, want 5", changes)
+	}
+}
+
+func Test_MyersDiff_Random(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	gen := func() []string {
+		s := make([]string, r.Intn(30))
+		for i := range s {
+			s[i] = strconv.Itoa(r.Intn(5))
+		}
+		return s
+	}
+
+	for n := 0; n < 500; n++ {
+		a, b := gen(), gen()
+		edits := MyersDiff(a, b)
+
+		var gotA, gotB []string
+		changes := 0
+		for _, e := range edits {
+			if e.Op != EditInsert {
+				gotA = append(gotA, e.Line)
+			}
+			if e.Op != EditDelete {
+				gotB = append(gotB, e.Line)
+			}
+			if e.Op != EditEqual {
+				changes++
+			}
+		}
+		if !reflect.DeepEqual(gotA, a) && (len(a) > 0 || len(gotA) > 0) {
+			t.Fatalf("MyersDiff(%v, %v) old side = %v", a, b, gotA)
+		}
+		if !reflect.DeepEqual(gotB, b) && (len(b) > 0 || len(gotB) > 0) {
+			t.Fatalf("MyersDiff(%v, %v) new side = %v", a, b, gotB)
+		}
+		if want := len(a) + len(b) - 2*lcsLength(a, b); changes != want {
+			t.Fatalf("MyersDiff(%v, %v) = %d changes, want %d", a, b, changes, want)
+		}
+
+		patch := UnifiedDiff("a", "b", edits, r.Intn(4))
+		got, err := ApplyUnifiedDiff(a, patch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, b) && (len(b) > 0 || len(got) > 0) {
+			t.Fatalf("ApplyUnifiedDiff(%v, %v) = %v, want %v", a, patch, got, b)
+		}
+	}
+}
+
+func Test_UnifiedDiff(t *testing.T) {
+	a := []string{"one", "two", "three", "four", "five", "six", "seven", "eight"}
+	b := []string{"one", "two", "3", "four", "five", "six", "seven", "eight", "nine"}
+
+	got := UnifiedDiff("old.txt", "new.txt", MyersDiff(a, b), 1)
+	want := []string{
+		"--- old.txt",
+		"+++ new.txt",
+		"@@ -2,3 +2,3 @@",
+		" two",
+		"-three",
+		"+3",
+		" four",
+		"@@ -8,1 +8,2 @@",
+		" eight",
+		"+nine",
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnifiedDiff() = %q, want %q", got, want)
+	}
+}
+
+func Test_ApplyUnifiedDiff_Mismatch(t *testing.T) {
+	patch := []string{"@@ -1,1 +1,1 @@", "-one", "+1"}
+	if _, err := ApplyUnifiedDiff([]string{"two"}, patch); err == nil {
+		t.Error("ApplyUnifiedDiff() expected error for mismatched line")
+	}
+}
+
+func lcsLength(a, b []string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			if a[i-1] == b[j-1] {
+				dp[i][j] = dp[i-1][j-1] + 1
+			} else if dp[i-1][j] > dp[i][j-1] {
+				dp[i][j] = dp[i-1][j]
+			} else {
+				dp[i][j] = dp[i][j-1]
+			}
+		}
+	}
+	return dp[len(a)][len(b)]
+}
@@ -0,0 +1,210 @@
+package iom
+
+import (
+	"container/list"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Partition is one file written by PartitionFiles
+type Partition struct {
+	Key   string `json:"key"`
+	File  string `json:"file"`
+	Lines int    `json:"lines"`
+}
+
+// PartitionOptions configures PartitionFiles
+type PartitionOptions struct {
+	// Key selects the value lines are partitioned by
+	Key KeySelector
+	// Dir is the directory partitions are written to
+	Dir string
+	// Ext is the extension of partition files
+	Ext string
+	// MaxOpen caps the number of partition files open at once
+	MaxOpen int
+}
+
+// SanitizeKeyFilename turns a key into a safe file name by replacing anything other than letters,
+// digits, '.', '-', '_', '@' and '+' with '_'. Leading dots are replaced so keys cannot name hidden
+// files or parent directories, and empty keys become "_empty"
+func SanitizeKeyFilename(key string) string {
+	var sb strings.Builder
+	for _, r := range key {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9',
+			r == '.', r == '-', r == '_', r == '@', r == '+':
+			sb.WriteRune(r)
+		default:
+			sb.WriteByte('_')
+		}
+	}
+
+	name := sb.String()
+	if len(name) > 200 {
+		name = name[:200]
+	}
+	if trimmed := strings.TrimLeft(name, "."); trimmed != name {
+		name = strings.Repeat("_", len(name)-len(trimmed)) + trimmed
+	}
+	if name == "" {
+		return "_empty"
+	}
+	return name
+}
+
+// partitioner writes lines to one file per key, keeping at most maxOpen files open and closing
+// the least recently used one when the cap is reached
+type partitioner struct {
+	opts  PartitionOptions
+	parts map[string]*Partition
+	names map[string]bool
+	open  map[string]*list.Element
+	lru   *list.List
+	// style is the line style of the file being partitioned
+	style *LineStyle
+}
+
+type openPartition struct {
+	key string
+	w   *LineWriter
+}
+
+func newPartitioner(opts PartitionOptions) *partitioner {
+	return &partitioner{
+		opts:  opts,
+		parts: make(map[string]*Partition),
+		names: make(map[string]bool),
+		open:  make(map[string]*list.Element),
+		lru:   list.New(),
+	}
+}
+
+func (p *partitioner) write(key, line string) error {
+	w, err := p.writer(key)
+	if err != nil {
+		return err
+	}
+	if err = w.Write(line); err != nil {
+		return err
+	}
+	p.parts[key].Lines++
+	return nil
+}
+
+func (p *partitioner) writer(key string) (*LineWriter, error) {
+	if e, ok := p.open[key]; ok {
+		p.lru.MoveToFront(e)
+		return e.Value.(*openPartition).w, nil
+	}
+
+	for p.lru.Len() > 0 && p.lru.Len() >= p.opts.MaxOpen {
+		oldest := p.lru.Back()
+		op := oldest.Value.(*openPartition)
+		if err := op.w.Close(); err != nil {
+			return nil, err
+		}
+		p.lru.Remove(oldest)
+		delete(p.open, op.key)
+	}
+
+	var w *LineWriter
+	var err error
+	if part, ok := p.parts[key]; ok {
+		w, err = AppendLineWriter(part.File, p.style)
+	} else {
+		part = &Partition{Key: key, File: p.filename(key)}
+		p.parts[key] = part
+		w, err = CreateLineWriter(part.File, p.style)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p.open[key] = p.lru.PushFront(&openPartition{key: key, w: w})
+	return w, nil
+}
+
+// filename returns a file name for a new key, numbering keys that sanitize to a taken name
+func (p *partitioner) filename(key string) string {
+	base := SanitizeKeyFilename(key)
+	name := base
+	for i := 2; p.names[strings.ToLower(name)]; i++ {
+		name = base + "-" + strconv.Itoa(i)
+	}
+	p.names[strings.ToLower(name)] = true
+	return filepath.Join(p.opts.Dir, name+p.opts.Ext)
+}
+
+func (p *partitioner) close() error {
+	var first error
+	for e := p.lru.Front(); e != nil; e = e.Next() {
+		if err := e.Value.(*openPartition).w.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	p.lru.Init()
+	p.open = make(map[string]*list.Element)
+	return first
+}
+
+func (p *partitioner) manifest() []Partition {
+	result := make([]Partition, 0, len(p.parts))
+	for _, part := range p.parts {
+		result = append(result, *part)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
+
+// PartitionFiles streams the lines of srcs into one file per key in opts.Dir and returns a
+// manifest of the written files sorted by key, and the number of lines without a key
+func PartitionFiles(srcs []string, opts PartitionOptions) ([]Partition, int, error) {
+	if opts.MaxOpen < 1 {
+		return nil, 0, fmt.Errorf("partition files: max open files must be positive")
+	}
+	if err := os.MkdirAll(opts.Dir, 0755); err != nil {
+		return nil, 0, fmt.Errorf("partition files: %w", err)
+	}
+
+	p := newPartitioner(opts)
+	skipped := 0
+	for _, src := range srcs {
+		r, err := OpenLineReader(src)
+		if err != nil {
+			p.close()
+			return nil, skipped, fmt.Errorf("partition files: %w", err)
+		}
+		p.style = r.Style()
+
+		for line, ok := r.Next(); ok; line, ok = r.Next() {
+			key, ok := opts.Key.Key(line)
+			if !ok {
+				skipped++
+				continue
+			}
+			if err = p.write(opts.Key.Format(key), line); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = r.Err()
+		}
+		r.Close()
+
+		if err != nil {
+			p.close()
+			return nil, skipped, fmt.Errorf("partition files: %s: %w", src, err)
+		}
+	}
+
+	if err := p.close(); err != nil {
+		return nil, skipped, fmt.Errorf("partition files: %w", err)
+	}
+
+	return p.manifest(), skipped, nil
+}
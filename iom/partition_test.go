@@ -0,0 +1,95 @@
+package iom
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_SanitizeKeyFilename(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"example.com", "example.com"},
+		{"2023/01/02", "2023_01_02"},
+		{"../etc", "___etc"},
+		{"", "_empty"},
+	}
+
+	for _, tt := range tests {
+		if got := SanitizeKeyFilename(tt.in); got != tt.want {
+			t.Errorf("SanitizeKeyFilename(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func Test_PartitionFiles(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "in.txt")
+	lines := []string{"a@x.com", "b@y.com", "c@x.com", "d@z.com", "e@y.com", "broken"}
+	if err := WriteFile(src, lines); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "out")
+
+	manifest, skipped, err := PartitionFiles([]string{src}, PartitionOptions{
+		Key:     KeySelector{Delim: "@", IDs: []int{1}},
+		Dir:     out,
+		Ext:     ".txt",
+		MaxOpen: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if skipped != 1 {
+		t.Errorf("PartitionFiles() skipped = %v, want 1", skipped)
+	}
+
+	want := []Partition{
+		{Key: "x.com", File: filepath.Join(out, "x.com.txt"), Lines: 2},
+		{Key: "y.com", File: filepath.Join(out, "y.com.txt"), Lines: 2},
+		{Key: "z.com", File: filepath.Join(out, "z.com.txt"), Lines: 1},
+	}
+	if !reflect.DeepEqual(manifest, want) {
+		t.Errorf("PartitionFiles() = %v, want %v", manifest, want)
+	}
+
+	got, err := ReadFile(want[1].File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, []string{"b@y.com", "e@y.com"}) {
+		t.Errorf("PartitionFiles() y.com.txt = %v", got)
+	}
+}
+
+func Test_PartitionFilesPreservesLineEndings(t *testing.T) {
+	setTestFileOptions(t, FileOptions{})
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "in.txt")
+	if err := os.WriteFile(src, []byte("a@x.com\r\nb@y.com\r\nc@x.com"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "out")
+	if _, _, err := PartitionFiles([]string{src}, PartitionOptions{
+		Key:     KeySelector{Delim: "@", IDs: []int{1}},
+		Dir:     out,
+		Ext:     ".txt",
+		MaxOpen: 1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{"x.com.txt": "a@x.com\r\nc@x.com", "y.com.txt": "b@y.com"}
+	for name, want := range tests {
+		if got, _ := os.ReadFile(filepath.Join(out, name)); string(got) != want {
+			t.Errorf("PartitionFiles() %s = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package iom
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//go:embed data/phone_metadata.txt
+var embeddedPhoneMetadata string
+
+// PhoneCountry is the numbering metadata of a country calling code
+type PhoneCountry struct {
+	Region string
+	Code   string
+	// Trunk is the national prefix dialled before local numbers, e.g. 0 in the UK
+	Trunk   string
+	Lengths map[int]struct{}
+}
+
+// ValidLength reports whether n is a valid length of a national significant number
+func (c PhoneCountry) ValidLength(n int) bool {
+	_, ok := c.Lengths[n]
+	return ok
+}
+
+// PhoneMetadata maps calling codes and regions to their numbering metadata
+type PhoneMetadata struct {
+	byCode   map[string]PhoneCountry
+	byRegion map[string]PhoneCountry
+}
+
+// ParsePhoneMetadata parses lines of "region code trunk lengths", e.g. "GB 44 0 9,10". Blank lines
+// and lines starting with # are skipped
+func ParsePhoneMetadata(lines []string) (*PhoneMetadata, error) {
+	m := &PhoneMetadata{byCode: make(map[string]PhoneCountry), byRegion: make(map[string]PhoneCountry)}
+	for n, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 4 || !isDigits(fields[1]) {
+			return nil, fmt.Errorf("parse phone metadata: line %d: expected region, code, trunk and lengths", n+1)
+		}
+
+		c := PhoneCountry{Region: strings.ToUpper(fields[0]), Code: fields[1], Lengths: make(map[int]struct{})}
+		if fields[2] != "-" {
+			c.Trunk = fields[2]
+		}
+		for _, v := range strings.Split(fields[3], ",") {
+			from, to, isRange := strings.Cut(v, "-")
+			if !isRange {
+				to = from
+			}
+			lo, err1 := strconv.Atoi(from)
+			hi, err2 := strconv.Atoi(to)
+			if err1 != nil || err2 != nil || lo < 1 || hi < lo {
+				return nil, fmt.Errorf("parse phone metadata: line %d: invalid lengths %q", n+1, fields[3])
+			}
+			for i := lo; i <= hi; i++ {
+				c.Lengths[i] = struct{}{}
+			}
+		}
+
+		if _, ok := m.byCode[c.Code]; !ok {
+			m.byCode[c.Code] = c
+		}
+		m.byRegion[c.Region] = c
+	}
+	return m, nil
+}
+
+// DefaultPhoneMetadata returns the embedded phone numbering metadata
+func DefaultPhoneMetadata() *PhoneMetadata {
+	m, err := ParsePhoneMetadata(strings.Split(embeddedPhoneMetadata, "\n"))
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Country looks up a region, e.g. GB, or a calling code, e.g. 44 or +44
+func (m *PhoneMetadata) Country(country string) (PhoneCountry, bool) {
+	country = strings.TrimPrefix(strings.TrimSpace(country), "+")
+	if c, ok := m.byRegion[strings.ToUpper(country)]; ok {
+		return c, true
+	}
+	c, ok := m.byCode[country]
+	return c, ok
+}
+
+// callingCode finds the calling code a string of digits starts with
+func (m *PhoneMetadata) callingCode(digits string) (PhoneCountry, bool) {
+	for i := 1; i <= 3 && i <= len(digits); i++ {
+		if c, ok := m.byCode[digits[:i]]; ok {
+			return c, true
+		}
+	}
+	return PhoneCountry{}, false
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizePhone normalizes a phone number to E.164, e.g. +442079460000. Spaces, dashes, dots,
+// slashes and parentheses are ignored. Numbers starting with + or 00 are international, others
+// are local numbers of country, which may be empty to only accept international numbers
+func NormalizePhone(s string, meta *PhoneMetadata, country string) (string, error) {
+	s = strings.TrimSpace(s)
+	international := strings.HasPrefix(s, "+")
+	s = strings.TrimPrefix(s, "+")
+
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '/' || r == '(' || r == ')':
+		default:
+			return "", fmt.Errorf("invalid character %q", r)
+		}
+	}
+	digits := b.String()
+	if digits == "" {
+		return "", errors.New("no digits")
+	}
+
+	if !international && strings.HasPrefix(digits, "00") {
+		international = true
+		digits = digits[2:]
+	}
+
+	var c PhoneCountry
+	var nsn string
+	if international {
+		var ok bool
+		c, ok = meta.callingCode(digits)
+		if !ok {
+			return "", errors.New("unknown calling code")
+		}
+		nsn = digits[len(c.Code):]
+	} else {
+		if country == "" {
+			return "", errors.New("no calling code and no default country")
+		}
+		var ok bool
+		c, ok = meta.Country(country)
+		if !ok {
+			return "", fmt.Errorf("unknown country %q", country)
+		}
+		nsn = digits
+	}
+
+	// Local numbers start with the trunk prefix, and international ones such as
+	// +44 (0)20 7946 0000 often repeat it
+	if c.Trunk != "" && strings.HasPrefix(nsn, c.Trunk) && c.ValidLength(len(nsn)-len(c.Trunk)) {
+		nsn = nsn[len(c.Trunk):]
+	}
+
+	if !c.ValidLength(len(nsn)) {
+		return "", fmt.Errorf("invalid length %d for calling code +%s", len(nsn), c.Code)
+	}
+	return "+" + c.Code + nsn, nil
+}
+
+// PhoneOptions configures NormalizePhones
+type PhoneOptions struct {
+	// Country is the region or calling code of local numbers
+	Country string
+	Meta    *PhoneMetadata
+	// Dedupe keeps the first line of each normalized number
+	Dedupe bool
+	// KeepOriginal writes the original line after the normalized number, separated by Delim
+	KeepOriginal bool
+	Delim        string
+}
+
+// NormalizePhones normalizes every line as a phone number. It returns the normalized lines and
+// the rejected lines, each followed by a tab and the reason
+func NormalizePhones(lines []string, opts PhoneOptions) ([]string, []string) {
+	if opts.Meta == nil {
+		opts.Meta = DefaultPhoneMetadata()
+	}
+
+	seen := make(map[string]struct{})
+	var result, rejected []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		phone, err := NormalizePhone(line, opts.Meta, opts.Country)
+		if err != nil {
+			rejected = append(rejected, line+"\t"+err.Error())
+			continue
+		}
+
+		if opts.Dedupe {
+			if _, ok := seen[phone]; ok {
+				continue
+			}
+			seen[phone] = struct{}{}
+		}
+
+		if opts.KeepOriginal {
+			phone += opts.Delim + line
+		}
+		result = append(result, phone)
+	}
+	return result, rejected
+}
+
+// NormalizePhonesFile normalizes the phone numbers of src into dst, writing rejected lines to
+// rejects unless it is empty. It returns the number of written and rejected lines
+func NormalizePhonesFile(src, dst, rejects string, opts PhoneOptions) (int, int, error) {
+	lines, style, err := ReadFileStyle(src)
+	if err != nil {
+		return 0, 0, fmt.Errorf("normalize phones file: %w", err)
+	}
+
+	result, rejected := NormalizePhones(lines, opts)
+
+	err = WriteFileStyle(dst, result, style)
+	if err != nil {
+		return 0, 0, fmt.Errorf("normalize phones file: %w", err)
+	}
+
+	if rejects != "" {
+		err = WriteFileStyle(rejects, rejected, style)
+		if err != nil {
+			return 0, 0, fmt.Errorf("normalize phones file: %w", err)
+		}
+	}
+
+	return len(result), len(rejected), nil
+}
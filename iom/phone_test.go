@@ -0,0 +1,83 @@
+package iom
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_NormalizePhone(t *testing.T) {
+	meta := DefaultPhoneMetadata()
+	tests := []struct {
+		in      string
+		country string
+		want    string
+	}{
+		{"+44 (0)20 7946 0000", "", "+442079460000"},
+		{"0044 20-7946-0000", "", "+442079460000"},
+		{"020 7946 0000", "GB", "+442079460000"},
+		{"(212) 555-1234", "US", "+12125551234"},
+		{"1-212-555-1234", "+1", "+12125551234"},
+		{"030 12345678", "DE", "+493012345678"},
+		{"8 800 123-45-67", "RU", "+78001234567"},
+		{"800 123 45 67", "RU", "+78001234567"},
+		{"06 30 123 4567", "HU", "+36301234567"},
+		{"+39 06 1234 5678", "", "+390612345678"},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizePhone(tt.in, meta, tt.country)
+		if err != nil {
+			t.Errorf("NormalizePhone(%q, %q) error = %v", tt.in, tt.country, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizePhone(%q, %q) = %q, want %q", tt.in, tt.country, got, tt.want)
+		}
+	}
+
+	invalid := []struct {
+		in      string
+		country string
+	}{
+		{"212 555 1234", ""},
+		{"212 555 1234", "XX"},
+		{"+1 212 555 12", ""},
+		{"+999 1234567", ""},
+		{"555-CALL-NOW", "US"},
+		{"()", "US"},
+	}
+	for _, tt := range invalid {
+		if got, err := NormalizePhone(tt.in, meta, tt.country); err == nil {
+			t.Errorf("NormalizePhone(%q, %q) = %q, expected error", tt.in, tt.country, got)
+		}
+	}
+}
+
+func Test_ParsePhoneMetadata(t *testing.T) {
+	m, err := ParsePhoneMetadata([]string{"# comment", "", "XA 999 0 5-6,8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, ok := m.Country("xa")
+	if !ok || c.Code != "999" || c.Trunk != "0" || !c.ValidLength(6) || c.ValidLength(7) || !c.ValidLength(8) {
+		t.Errorf("ParsePhoneMetadata() = %+v, %v", c, ok)
+	}
+
+	if _, err := ParsePhoneMetadata([]string{"XA 999 0 6-5"}); err == nil {
+		t.Error("ParsePhoneMetadata() expected error for invalid lengths")
+	}
+}
+
+func Test_NormalizePhones(t *testing.T) {
+	lines := []string{"020 7946 0000", "+44 20 7946 0000", "07911 123456", "nope"}
+	got, rejected := NormalizePhones(lines, PhoneOptions{Country: "GB", Dedupe: true, KeepOriginal: true, Delim: "\t"})
+
+	want := []string{"+442079460000\t020 7946 0000", "+447911123456\t07911 123456"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NormalizePhones() = %q, want %q", got, want)
+	}
+	if len(rejected) != 1 {
+		t.Errorf("NormalizePhones() rejected = %v, want 1 line", rejected)
+	}
+}
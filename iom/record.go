@@ -0,0 +1,240 @@
+package iom
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Record separators accepted by FileOptions.RecordSep besides literals and regexes
+const (
+	RecordSepNewline   = "newline"
+	RecordSepNUL       = "nul"
+	RecordSepParagraph = "paragraph"
+	// RecordSepRegexPrefix starts a regex separator, e.g. re:;\s*
+	RecordSepRegexPrefix = "re:"
+)
+
+const (
+	sepNewline = iota
+	sepParagraph
+	sepLiteral
+	sepRegex
+)
+
+// recordSep is how records of a file are separated
+type recordSep struct {
+	kind    int
+	literal []byte
+	re      *regexp.Regexp
+}
+
+var (
+	readSep  = &recordSep{kind: sepNewline}
+	writeSep = &recordSep{kind: sepNewline}
+)
+
+// parseRecordSep parses a record separator: newline, nul, paragraph for blocks of lines separated
+// by blank lines, re:<regex>, or a literal which may use Go escapes such as \t or \x1e
+func parseRecordSep(s string) (*recordSep, error) {
+	switch strings.ToLower(s) {
+	case "", RecordSepNewline:
+		return &recordSep{kind: sepNewline}, nil
+	case RecordSepNUL:
+		return &recordSep{kind: sepLiteral, literal: []byte{0}}, nil
+	case RecordSepParagraph:
+		return &recordSep{kind: sepParagraph}, nil
+	}
+
+	if strings.HasPrefix(s, RecordSepRegexPrefix) {
+		re, err := regexp.Compile(strings.TrimPrefix(s, RecordSepRegexPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid record separator: %w", err)
+		}
+		if re.MatchString("") {
+			return nil, fmt.Errorf("invalid record separator %q: matches the empty string", s)
+		}
+		return &recordSep{kind: sepRegex, re: re}, nil
+	}
+
+	literal := s
+	if strings.Contains(s, `\`) {
+		unquoted, err := strconv.Unquote(`"` + strings.ReplaceAll(s, `"`, `\"`) + `"`)
+		if err != nil {
+			return nil, fmt.Errorf("invalid record separator %q: %w", s, err)
+		}
+		literal = unquoted
+	}
+	if literal == "\n" || literal == "\r\n" {
+		return &recordSep{kind: sepNewline}, nil
+	}
+	return &recordSep{kind: sepLiteral, literal: []byte(literal)}, nil
+}
+
+// setRecordSeps sets the separators of read and written records. Written records use the read
+// separator unless out is set, or it is a regex, which writes newlines
+func setRecordSeps(in, out string) error {
+	r, err := parseRecordSep(in)
+	if err != nil {
+		return err
+	}
+
+	w := r
+	if out != "" {
+		if w, err = parseRecordSep(out); err != nil {
+			return fmt.Errorf("output %w", err)
+		}
+	}
+	if w.kind == sepRegex {
+		if out != "" {
+			return errors.New("output record separator cannot be a regex")
+		}
+		w = &recordSep{kind: sepNewline}
+	}
+
+	readSep, writeSep = r, w
+	return nil
+}
+
+// separators returns the separator written between records and the one ending the last record
+func (s *recordSep) separators(style LineStyle) (string, string) {
+	switch s.kind {
+	case sepParagraph:
+		return style.newline() + style.newline(), style.newline()
+	case sepLiteral:
+		return string(s.literal), string(s.literal)
+	}
+	return style.newline(), style.newline()
+}
+
+// terminator returns what a file ends with if its last record is terminated
+func (s *recordSep) terminator() string {
+	if s.kind == sepLiteral {
+		return string(s.literal)
+	}
+	return "\n"
+}
+
+// matchesNUL reports whether the separator matches a NUL byte
+func (s *recordSep) matchesNUL() bool {
+	switch s.kind {
+	case sepLiteral:
+		return bytes.IndexByte(s.literal, 0) >= 0
+	case sepRegex:
+		return s.re.Match([]byte{0})
+	}
+	return false
+}
+
+// tooLong returns the error of a record longer than the maximum
+func (r *LineReader) tooLong() error {
+	return fmt.Errorf("%s:%d: %w: longer than %d bytes", r.file, r.line+1, ErrLineTooLong, r.max)
+}
+
+// readParagraph reads lines up to a blank line, or the end of the file, joined by \n. Blank lines
+// between records are skipped
+func (r *LineReader) readParagraph() ([]byte, bool, error) {
+	var rec []byte
+	for {
+		line, ok, err := r.readLine()
+		if err != nil || !ok {
+			return rec, rec != nil, err
+		}
+
+		if len(bytes.TrimSpace(line)) == 0 {
+			if rec != nil {
+				return rec, true, nil
+			}
+			continue
+		}
+
+		if rec != nil {
+			rec = append(rec, '\n')
+		}
+		rec = append(rec, line...)
+		if r.max > 0 && len(rec) > r.max {
+			return nil, false, r.tooLong()
+		}
+	}
+}
+
+// readUntil reads up to the next literal separator
+func (r *LineReader) readUntil(sep []byte) ([]byte, bool, error) {
+	var buf []byte
+	for {
+		chunk, err := r.r.ReadSlice(sep[len(sep)-1])
+		buf = append(buf, chunk...)
+		if r.max > 0 && len(buf) > r.max+len(sep) {
+			return nil, false, r.tooLong()
+		}
+
+		switch {
+		case err == bufio.ErrBufferFull:
+			continue
+		case err == io.EOF:
+			r.splitter.terminated = false
+			return buf, len(buf) > 0, nil
+		case err != nil:
+			return nil, false, err
+		}
+
+		if bytes.HasSuffix(buf, sep) {
+			r.splitter.terminated = true
+			return buf[:len(buf)-len(sep)], true, nil
+		}
+	}
+}
+
+// regexChunkSize is how much input is read at a time for regex separated records. Separators are
+// assumed to be shorter
+const regexChunkSize = 32 * 1024
+
+// readRegex reads up to the next match of a regex separator. A match must be followed by more
+// input, or the end of the file, so a separator such as \n+ is not cut short by the buffer
+func (r *LineReader) readRegex(re *regexp.Regexp) ([]byte, bool, error) {
+	if r.chunk == nil {
+		r.chunk = make([]byte, regexChunkSize)
+	}
+
+	for {
+		if loc := re.FindIndex(r.pending[r.searched:]); loc != nil {
+			start, end := r.searched+loc[0], r.searched+loc[1]
+			if end < len(r.pending) || r.eof {
+				rec := r.pending[:start:start]
+				r.pending = r.pending[end:]
+				r.searched = 0
+				r.splitter.terminated = true
+				return rec, true, nil
+			}
+		}
+
+		if r.eof {
+			rec := r.pending
+			r.pending = nil
+			r.splitter.terminated = false
+			return rec, len(rec) > 0, nil
+		}
+
+		if r.max > 0 && len(r.pending) > r.max+len(r.chunk) {
+			return nil, false, r.tooLong()
+		}
+
+		// only the last chunk can hold the start of a separator cut short by the end of the input
+		if n := len(r.pending) - len(r.chunk); n > r.searched {
+			r.searched = n
+		}
+
+		n, err := r.r.Read(r.chunk)
+		r.pending = append(r.pending, r.chunk[:n]...)
+		if err == io.EOF {
+			r.eof = true
+		} else if err != nil {
+			return nil, false, err
+		}
+	}
+}
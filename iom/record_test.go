@@ -0,0 +1,180 @@
+package iom
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_ReadFileRecordSep(t *testing.T) {
+	tests := []struct {
+		name    string
+		sep     string
+		content string
+		want    []string
+	}{
+		{"nul", RecordSepNUL, "a b\x00c\nd\x00", []string{"a b", "c\nd"}},
+		{"nul unterminated", RecordSepNUL, "a\x00b", []string{"a", "b"}},
+		{"paragraph", RecordSepParagraph, "\n1 Main St\nSpringfield\n\n \n2 Oak Ave\r\nShelbyville", []string{"1 Main St\nSpringfield", "2 Oak Ave\nShelbyville"}},
+		{"literal", `\x1e`, "a\x1eb\x1e", []string{"a", "b"}},
+		{"multi byte literal", "--", "a-b--c---d", []string{"a-b", "c", "-d"}},
+		{"regex", `re:;\s*`, "a; b;\n\nc;", []string{"a", "b", "c"}},
+		{"regex greedy across reads", `re:x+`, strings.Repeat("a", 40000) + strings.Repeat("x", 40000) + "b", []string{strings.Repeat("a", 40000), "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestFileOptions(t, FileOptions{RecordSep: tt.sep})
+
+			file := filepath.Join(t.TempDir(), "list.txt")
+			if err := os.WriteFile(file, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_WriteFileRecordSep(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  FileOptions
+		want  string
+		final string
+	}{
+		{"nul", FileOptions{RecordSep: RecordSepNUL}, "x y\x00z\x00", "x y\x00z\x00w\x00"},
+		{"paragraph", FileOptions{RecordSep: RecordSepParagraph}, "x y\n\nz\n", "x y\n\nz\n\nw\n"},
+		{"paragraph crlf", FileOptions{RecordSep: RecordSepParagraph, EOL: EOLCRLF}, "x y\r\n\r\nz\r\n", "x y\r\n\r\nz\r\n\r\nw\r\n"},
+		{"regex writes newlines", FileOptions{RecordSep: `re:,+`}, "x y\nz\n", "x y\nz\nw\n"},
+		{"output sep", FileOptions{RecordSep: RecordSepNUL, OutputRecordSep: RecordSepNewline}, "x y\nz\n", "x y\nz\nw\n"},
+		{"no final sep", FileOptions{RecordSep: ",", FinalNewline: FinalNewlineNever}, "x y,z", "x y,z,w"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestFileOptions(t, tt.opts)
+
+			records := []string{"x y", "z"}
+			if tt.opts.RecordSep == RecordSepParagraph {
+				records[0] = "x\ny"
+				tt.want = strings.Replace(tt.want, "x y", "x"+newlineOf(tt.opts)+"y", 1)
+				tt.final = strings.Replace(tt.final, "x y", "x"+newlineOf(tt.opts)+"y", 1)
+			}
+
+			file := filepath.Join(t.TempDir(), "out.txt")
+			if err := WriteFile(file, records); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := os.ReadFile(file); string(got) != tt.want {
+				t.Errorf("WriteFile() wrote %q, want %q", got, tt.want)
+			}
+
+			if err := AppendFile(file, []string{"w"}); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := os.ReadFile(file); string(got) != tt.final {
+				t.Errorf("AppendFile() wrote %q, want %q", got, tt.final)
+			}
+
+			if tt.opts.OutputRecordSep == "" && !strings.HasPrefix(tt.opts.RecordSep, RecordSepRegexPrefix) {
+				got, err := ReadFile(file)
+				if want := append(records, "w"); err != nil || !reflect.DeepEqual(got, want) {
+					t.Errorf("ReadFile() = %q, %v, want %q", got, err, want)
+				}
+			}
+		})
+	}
+}
+
+func newlineOf(opts FileOptions) string {
+	if opts.EOL == EOLCRLF {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+func Test_AppendFileRecordSepUnterminated(t *testing.T) {
+	setTestFileOptions(t, FileOptions{RecordSep: RecordSepParagraph})
+
+	file := filepath.Join(t.TempDir(), "out.txt")
+	if err := os.WriteFile(file, []byte("a\nb"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := AppendFile(file, []string{"c"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(file); string(got) != "a\nb\n\nc\n" {
+		t.Errorf("AppendFile() wrote %q, want %q", got, "a\nb\n\nc\n")
+	}
+}
+
+func Test_RecordSepMaxLineLength(t *testing.T) {
+	for _, sep := range []string{RecordSepNUL, RecordSepParagraph, `re:\x00`} {
+		t.Run(sep, func(t *testing.T) {
+			setTestFileOptions(t, FileOptions{RecordSep: sep, MaxLineLength: 5})
+
+			file := filepath.Join(t.TempDir(), "list.txt")
+			if err := os.WriteFile(file, []byte("abc\x00\n\nabc\ndef\x00"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := ReadFile(file)
+			if err == nil || !strings.Contains(err.Error(), ErrLineTooLong.Error()) {
+				t.Errorf("ReadFile() error = %v, want %v", err, ErrLineTooLong)
+			}
+		})
+	}
+}
+
+func Test_ReadConfigFileIgnoresRecordOptions(t *testing.T) {
+	setTestFileOptions(t, FileOptions{RecordSep: RecordSepNUL, MaxLineLength: 3})
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "script.txt")
+	if err := os.WriteFile(script, []byte("trim\r\nreplace:a,b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadConfigFile(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"trim", "replace:a,b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadConfigFile() = %q, want %q", got, want)
+	}
+
+	hashes := filepath.Join(dir, "hashes.txt")
+	if err := os.WriteFile(hashes, []byte("ABCDEF\n012345\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := ReadHashesFile(hashes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]struct{}{"abcdef": {}, "012345": {}}; !reflect.DeepEqual(m, want) {
+		t.Errorf("ReadHashesFile() = %v, want %v", m, want)
+	}
+}
+
+func Test_SetFileOptionsRecordSep(t *testing.T) {
+	setTestFileOptions(t, FileOptions{})
+
+	for _, opts := range []FileOptions{
+		{RecordSep: "re:("},
+		{RecordSep: "re:x*"},
+		{RecordSep: `\q`},
+		{RecordSep: RecordSepNUL, OutputRecordSep: "re:x"},
+	} {
+		if err := SetFileOptions(opts); err == nil {
+			t.Errorf("SetFileOptions(%+v) expected error", opts)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package iom
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Transform rewrites a single line
+type Transform func(string) string
+
+// TransformChain applies transforms in order
+type TransformChain []Transform
+
+// Apply runs every transform of the chain over a line
+func (c TransformChain) Apply(line string) string {
+	for _, t := range c {
+		line = t(line)
+	}
+	return line
+}
+
+// ParseTransforms parses one operation per entry into a TransformChain. Empty entries and entries
+// starting with '#' are ignored, so a script file can be passed line by line. Arguments are split on
+// whitespace and may be quoted with '...' or "...". Operations:
+//
+//	replace <regex> <replacement>     regex replace, $1 style capture groups
+//	prefix <text>, suffix <text>      add text
+//	strip-prefix <text>               remove text if present
+//	strip-suffix <text>
+//	lower, upper, title               case conversion
+//	trim [cutset]                     trim whitespace or the given characters
+//	pad-left <width> [char]           pad to width characters, with spaces by default
+//	pad-right <width> [char]
+//	truncate <width>                  cut to at most width characters
+//	delim <delim>                     delimiter of the following field operations, default ","
+//	fields <ids>                      reorder or select fields, e.g. 2,0,1
+//	field-replace <id> <regex> <replacement>
+func ParseTransforms(ops []string) (TransformChain, error) {
+	var chain TransformChain
+	delim := ","
+
+	for n, op := range ops {
+		op = strings.TrimSpace(op)
+		if op == "" || strings.HasPrefix(op, "#") {
+			continue
+		}
+
+		args, err := splitArgs(op)
+		if err != nil {
+			return nil, fmt.Errorf("parse transforms: op %d: %w", n+1, err)
+		}
+
+		if args[0] == "delim" {
+			if len(args) != 2 || args[1] == "" {
+				return nil, fmt.Errorf("parse transforms: op %d: delim takes one argument", n+1)
+			}
+			delim = args[1]
+			continue
+		}
+
+		t, err := parseTransform(args, delim)
+		if err != nil {
+			return nil, fmt.Errorf("parse transforms: op %d: %w", n+1, err)
+		}
+		chain = append(chain, t)
+	}
+
+	return chain, nil
+}
+
+func parseTransform(args []string, delim string) (Transform, error) {
+	name, args := args[0], args[1:]
+
+	want := map[string][]int{
+		"replace":       {2},
+		"prefix":        {1},
+		"suffix":        {1},
+		"strip-prefix":  {1},
+		"strip-suffix":  {1},
+		"lower":         {0},
+		"upper":         {0},
+		"title":         {0},
+		"trim":          {0, 1},
+		"pad-left":      {1, 2},
+		"pad-right":     {1, 2},
+		"truncate":      {1},
+		"fields":        {1},
+		"field-replace": {3},
+	}
+	counts, ok := want[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown operation %q", name)
+	}
+	if len(args) < counts[0] || len(args) > counts[len(counts)-1] {
+		return nil, fmt.Errorf("%s takes %d argument(s), got %d", name, counts[0], len(args))
+	}
+
+	switch name {
+	case "replace":
+		re, err := regexp.Compile(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("replace: %w", err)
+		}
+		repl := args[1]
+		return func(s string) string { return re.ReplaceAllString(s, repl) }, nil
+	case "prefix":
+		return func(s string) string { return args[0] + s }, nil
+	case "suffix":
+		return func(s string) string { return s + args[0] }, nil
+	case "strip-prefix":
+		return func(s string) string { return strings.TrimPrefix(s, args[0]) }, nil
+	case "strip-suffix":
+		return func(s string) string { return strings.TrimSuffix(s, args[0]) }, nil
+	case "lower":
+		return strings.ToLower, nil
+	case "upper":
+		return strings.ToUpper, nil
+	case "title":
+		return titleCase, nil
+	case "trim":
+		if len(args) == 0 {
+			return strings.TrimSpace, nil
+		}
+		return func(s string) string { return strings.Trim(s, args[0]) }, nil
+	case "pad-left", "pad-right":
+		width, err := strconv.Atoi(args[0])
+		if err != nil || width < 0 {
+			return nil, fmt.Errorf("%s: invalid width %q", name, args[0])
+		}
+		pad := " "
+		if len(args) == 2 {
+			if utf8.RuneCountInString(args[1]) != 1 {
+				return nil, fmt.Errorf("%s: pad must be a single character", name)
+			}
+			pad = args[1]
+		}
+		left := name == "pad-left"
+		return func(s string) string {
+			n := width - utf8.RuneCountInString(s)
+			if n <= 0 {
+				return s
+			}
+			if left {
+				return strings.Repeat(pad, n) + s
+			}
+			return s + strings.Repeat(pad, n)
+		}, nil
+	case "truncate":
+		width, err := strconv.Atoi(args[0])
+		if err != nil || width < 0 {
+			return nil, fmt.Errorf("truncate: invalid width %q", args[0])
+		}
+		return func(s string) string {
+			if utf8.RuneCountInString(s) <= width {
+				return s
+			}
+			return string([]rune(s)[:width])
+		}, nil
+	case "fields":
+		key, err := ParseKeySelector(delim, args[0])
+		if err != nil {
+			return nil, fmt.Errorf("fields: %w", err)
+		}
+		return func(s string) string {
+			fields := strings.Split(s, delim)
+			out := make([]string, len(key.IDs))
+			for i, id := range key.IDs {
+				if id < len(fields) {
+					out[i] = fields[id]
+				}
+			}
+			return strings.Join(out, delim)
+		}, nil
+	default: // field-replace
+		id, err := strconv.Atoi(args[0])
+		if err != nil || id < 0 {
+			return nil, fmt.Errorf("field-replace: invalid field id %q", args[0])
+		}
+		re, err := regexp.Compile(args[1])
+		if err != nil {
+			return nil, fmt.Errorf("field-replace: %w", err)
+		}
+		repl := args[2]
+		return func(s string) string {
+			fields := strings.Split(s, delim)
+			if id >= len(fields) {
+				return s
+			}
+			fields[id] = re.ReplaceAllString(fields[id], repl)
+			return strings.Join(fields, delim)
+		}, nil
+	}
+}
+
+// splitArgs splits an operation into whitespace separated arguments, honouring single quotes and
+// double quotes, inside which a backslash escapes the next character
+func splitArgs(s string) ([]string, error) {
+	var args []string
+	var sb strings.Builder
+	inArg := false
+	var quote rune
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				sb.WriteRune(r)
+			}
+		case quote == '"':
+			if r == '\\' && i+1 < len(runes) {
+				i++
+				sb.WriteRune(runes[i])
+			} else if r == '"' {
+				quote = 0
+			} else {
+				sb.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, sb.String())
+				sb.Reset()
+				inArg = false
+			}
+		default:
+			sb.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+	if inArg {
+		args = append(args, sb.String())
+	}
+	return args, nil
+}
+
+// titleCase upper cases the first letter of every word and lower cases the rest
+func titleCase(s string) string {
+	var sb strings.Builder
+	start := true
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start {
+				sb.WriteRune(unicode.ToUpper(r))
+			} else {
+				sb.WriteRune(unicode.ToLower(r))
+			}
+			start = false
+		} else {
+			sb.WriteRune(r)
+			start = true
+		}
+	}
+	return sb.String()
+}
+
+// TransformLines applies a chain to every line and returns the number of lines it changed
+func TransformLines(lines []string, chain TransformChain) (int, []string) {
+	result := make([]string, len(lines))
+	n := 0
+	for i, line := range lines {
+		result[i] = chain.Apply(line)
+		if result[i] != line {
+			n++
+		}
+	}
+	return n, result
+}
+
+// TransformFile applies a chain to every line of a file
+func TransformFile(src, dst string, chain TransformChain) (int, error) {
+	lines, style, err := ReadFileStyle(src)
+	if err != nil {
+		return 0, fmt.Errorf("transform file: %w", err)
+	}
+
+	n, lines := TransformLines(lines, chain)
+
+	err = WriteFileStyle(dst, lines, style)
+	if err != nil {
+		return n, fmt.Errorf("transform file: %w", err)
+	}
+
+	return n, nil
+}
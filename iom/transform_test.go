@@ -0,0 +1,59 @@
+package iom
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_ParseTransforms(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  []string
+		in   string
+		want string
+	}{
+		{"replace", []string{`replace '(\w+)@(\w+)' '$2:$1'`}, "ann@example", "example:ann"},
+		{"prefix suffix", []string{"prefix <", "suffix >"}, "a", "<a>"},
+		{"strip", []string{"strip-prefix http://", "strip-suffix /"}, "http://x.com/", "x.com"},
+		{"case", []string{"lower", "title"}, "HELLO wORLD", "Hello World"},
+		{"trim", []string{"trim", `trim "-"`}, "  --a--  ", "a"},
+		{"pad", []string{"pad-left 5 0"}, "42", "00042"},
+		{"truncate", []string{"truncate 3"}, "abcdef", "abc"},
+		{"fields", []string{"delim ;", "fields 2,0"}, "a;b;c", "c;a"},
+		{"field replace", []string{"field-replace 1 [0-9] #"}, "a1,b22,c3", "a1,b##,c3"},
+		{"comments", []string{"# a comment", "", "upper"}, "a", "A"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			chain, err := ParseTransforms(tt.ops)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := chain.Apply(tt.in); got != tt.want {
+				t.Errorf("Apply(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ParseTransforms_Invalid(t *testing.T) {
+	for _, op := range []string{"shout", "prefix", "pad-left x", "replace ( x", "prefix 'open"} {
+		if _, err := ParseTransforms([]string{op}); err == nil {
+			t.Errorf("ParseTransforms(%q) expected error", op)
+		}
+	}
+}
+
+func Test_splitArgs(t *testing.T) {
+	got, err := splitArgs(`replace "a \"b\"" 'c d' e`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"replace", `a "b"`, "c d", "e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("splitArgs() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,274 @@
+package iom
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// trackingParams are query parameters that only track where a visitor came from
+var trackingParams = map[string]struct{}{
+	"fbclid": {}, "gclid": {}, "dclid": {}, "gbraid": {}, "wbraid": {}, "msclkid": {}, "yclid": {},
+	"mc_cid": {}, "mc_eid": {}, "igshid": {}, "_ga": {}, "_gl": {}, "_hsenc": {}, "_hsmi": {},
+}
+
+// schemeRe matches the scheme at the start of an absolute URL
+var schemeRe = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*://`)
+
+var defaultPorts = map[string]string{"http": "80", "https": "443", "ftp": "21", "ws": "80", "wss": "443"}
+
+// IsTrackingParam reports whether a query parameter is a known tracking parameter, including
+// every utm_* parameter
+func IsTrackingParam(name string) bool {
+	name = strings.ToLower(name)
+	if strings.HasPrefix(name, "utm_") {
+		return true
+	}
+	_, ok := trackingParams[name]
+	return ok
+}
+
+// ParseURL parses a line as an absolute URL. Lines without a scheme, such as "example.com/a", are
+// parsed as http URLs
+func ParseURL(raw string) (*url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, errors.New("empty url")
+	}
+	if !schemeRe.MatchString(raw) {
+		raw = "http://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, errors.New("missing host")
+	}
+	return u, nil
+}
+
+// CanonicalizeURL returns the canonical form of a URL: scheme and host lower cased, default port
+// and fragment removed, dot segments resolved, tracking parameters and any parameters in strip
+// removed and the remaining query parameters sorted
+func CanonicalizeURL(raw string, strip []string) (string, error) {
+	u, err := ParseURL(raw)
+	if err != nil {
+		return "", err
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	port := u.Port()
+	if port == defaultPorts[u.Scheme] {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+
+	// dot segments are removed from the escaped path, so escaped separators such as %2F stay
+	// part of their segment
+	p := u.EscapedPath()
+	if p == "" {
+		p = "/"
+	}
+	cleaned := path.Clean(p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	u.RawPath = canonicalEscapedPath(cleaned)
+	u.Path, _ = url.PathUnescape(u.RawPath)
+
+	stripped := make(map[string]bool, len(strip))
+	for _, s := range strip {
+		stripped[strings.ToLower(s)] = true
+	}
+
+	var params []string
+	for _, pair := range strings.Split(u.RawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(pair, "=")
+		name, err := url.QueryUnescape(k)
+		if err != nil {
+			name = k
+		}
+		if IsTrackingParam(name) || stripped[strings.ToLower(name)] {
+			continue
+		}
+		value, err := url.QueryUnescape(v)
+		if err != nil {
+			value = v
+		}
+		param := url.QueryEscape(name)
+		if strings.Contains(pair, "=") {
+			param += "=" + url.QueryEscape(value)
+		}
+		params = append(params, param)
+	}
+	sort.Strings(params)
+
+	u.RawQuery = strings.Join(params, "&")
+	u.ForceQuery = false
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	return u.String(), nil
+}
+
+// canonicalEscapedPath escapes each segment of an escaped path the same way, whatever escapes it
+// was written with, keeping the escapes of reserved characters such as / ? and #
+func canonicalEscapedPath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, seg := range segments {
+		unescaped, err := url.PathUnescape(seg)
+		if err != nil {
+			continue
+		}
+		segments[i] = strings.ReplaceAll((&url.URL{Path: unescaped}).EscapedPath(), "/", "%2F")
+	}
+	return strings.Join(segments, "/")
+}
+
+// URL parts accepted by URLPart, besides "param:{name}"
+const (
+	URLPartHost = "host"
+	URLPartPath = "path"
+)
+
+// URLPart extracts the lower cased host, the path or, for "param:{name}", the value of a query
+// parameter of a URL. It returns false if the parameter is missing
+func URLPart(raw, part string) (string, bool, error) {
+	u, err := ParseURL(raw)
+	if err != nil {
+		return "", false, err
+	}
+
+	switch {
+	case part == URLPartHost:
+		return strings.TrimSuffix(strings.ToLower(u.Hostname()), "."), true, nil
+	case part == URLPartPath:
+		if u.Path == "" {
+			return "/", true, nil
+		}
+		return u.Path, true, nil
+	case strings.HasPrefix(part, "param:"):
+		q, err := url.ParseQuery(u.RawQuery)
+		if err != nil {
+			return "", false, err
+		}
+		name := strings.TrimPrefix(part, "param:")
+		if _, ok := q[name]; !ok {
+			return "", false, nil
+		}
+		return q.Get(name), true, nil
+	}
+
+	return "", false, fmt.Errorf("unknown url part %q", part)
+}
+
+// URLOptions configures ProcessURLs
+type URLOptions struct {
+	// Canonicalize writes the canonical form of each URL instead of the original line
+	Canonicalize bool
+	// Extract writes a part of each original URL instead: host, path or param:{name}
+	Extract string
+	// Dedupe keeps only the first line of each canonical form
+	Dedupe bool
+	// Strip lists query parameters to remove besides the tracking parameters
+	Strip []string
+}
+
+// URLStats counts the lines handled by ProcessURLs
+type URLStats struct {
+	Written    int
+	Duplicates int
+	Missing    int
+	Rejected   int
+}
+
+// ProcessURLs parses every line as a URL and returns the output lines and the rejected lines, each
+// followed by a tab and the parse error
+func ProcessURLs(lines []string, opts URLOptions) ([]string, []string, URLStats, error) {
+	var result, rejected []string
+	var stats URLStats
+	seen := make(map[string]struct{})
+
+	for _, line := range lines {
+		canonical, err := CanonicalizeURL(line, opts.Strip)
+		if err != nil {
+			rejected = append(rejected, line+"\t"+err.Error())
+			stats.Rejected++
+			continue
+		}
+
+		if opts.Dedupe {
+			if _, ok := seen[canonical]; ok {
+				stats.Duplicates++
+				continue
+			}
+			seen[canonical] = struct{}{}
+		}
+
+		out := line
+		switch {
+		case opts.Extract != "":
+			// the original URL still has the tracking parameters canonicalization removes
+			v, ok, err := URLPart(line, opts.Extract)
+			if err != nil {
+				return nil, nil, stats, err
+			}
+			if !ok {
+				stats.Missing++
+				continue
+			}
+			out = v
+		case opts.Canonicalize:
+			out = canonical
+		}
+
+		result = append(result, out)
+		stats.Written++
+	}
+
+	return result, rejected, stats, nil
+}
+
+// ProcessURLsFile processes the URLs of src into dst, writing rejected lines to rejects unless it
+// is empty
+func ProcessURLsFile(src, dst, rejects string, opts URLOptions) (URLStats, error) {
+	lines, style, err := ReadFileStyle(src)
+	if err != nil {
+		return URLStats{}, fmt.Errorf("process urls file: %w", err)
+	}
+
+	result, rejected, stats, err := ProcessURLs(lines, opts)
+	if err != nil {
+		return stats, fmt.Errorf("process urls file: %w", err)
+	}
+
+	err = WriteFileStyle(dst, result, style)
+	if err != nil {
+		return stats, fmt.Errorf("process urls file: %w", err)
+	}
+
+	if rejects != "" {
+		err = WriteFileStyle(rejects, rejected, style)
+		if err != nil {
+			return stats, fmt.Errorf("process urls file: %w", err)
+		}
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,92 @@
+package iom
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_CanonicalizeURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"HTTP://Example.COM:80/a/./b/../c?b=2&a=1#frag", "http://example.com/a/c?a=1&b=2"},
+		{"https://example.com:443", "https://example.com/"},
+		{"https://example.com:8443/x/", "https://example.com:8443/x/"},
+		{"example.com/page?utm_source=x&id=7&fbclid=abc", "http://example.com/page?id=7"},
+		{"https://[2001:DB8::1]:443/a", "https://[2001:db8::1]/a"},
+		{"https://example.com/a%20b?q=a+b", "https://example.com/a%20b?q=a+b"},
+		{"example.com/r?to=http://x.com", "http://example.com/r?to=http%3A%2F%2Fx.com"},
+		{"example.com/a%2Fb/../c%3Fd%23e/%7e", "http://example.com/c%3Fd%23e/~"},
+		{"example.com/a%2Fb", "http://example.com/a%2Fb"},
+	}
+
+	for _, tt := range tests {
+		got, err := CanonicalizeURL(tt.in, nil)
+		if err != nil {
+			t.Fatalf("CanonicalizeURL(%q) error = %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Errorf("CanonicalizeURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	if _, err := CanonicalizeURL("http://", nil); err == nil {
+		t.Error("CanonicalizeURL() expected error for missing host")
+	}
+}
+
+func Test_URLPart(t *testing.T) {
+	tests := []struct {
+		part string
+		want string
+		ok   bool
+	}{
+		{URLPartHost, "www.example.com", true},
+		{URLPartPath, "/a/b", true},
+		{"param:id", "42", true},
+		{"param:missing", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok, err := URLPart("https://WWW.Example.com/a/b?id=42", tt.part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("URLPart(%q) = %q %v, want %q %v", tt.part, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func Test_ProcessURLs_Dedupe(t *testing.T) {
+	lines := []string{
+		"https://example.com/a?utm_source=news",
+		"HTTPS://EXAMPLE.COM:443/a",
+		"https://example.com/b",
+		"http://",
+	}
+
+	got, rejected, stats, err := ProcessURLs(lines, URLOptions{Dedupe: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, []string{lines[0], lines[2]}) {
+		t.Errorf("ProcessURLs() = %v", got)
+	}
+	if len(rejected) != 1 || stats.Duplicates != 1 {
+		t.Errorf("ProcessURLs() rejected = %v, stats = %+v", rejected, stats)
+	}
+}
+
+func Test_ProcessURLs_ExtractTrackingParam(t *testing.T) {
+	lines := []string{"https://example.com/a?utm_source=news&id=1", "https://example.com/b"}
+
+	got, _, stats, err := ProcessURLs(lines, URLOptions{Extract: "param:utm_source"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, []string{"news"}) || stats.Missing != 1 {
+		t.Errorf("ProcessURLs() = %v, stats = %+v", got, stats)
+	}
+}